@@ -0,0 +1,184 @@
+package libdynv6
+
+import (
+	"context"
+	"time"
+
+	"github.com/ZxwyProject/dynv6"
+	"github.com/libdns/libdns"
+)
+
+// All calls to the dynv6 API go through the methods in this file,
+// so that statistics, auditing, dry-run and error wrapping are applied consistently.
+// Methods taking a *dynv6.Zone expect it to be resolved by p.zone first.
+
+// zones returns the zones of all accounts. If a zone is visible to
+// several accounts, it is returned once.
+func (p *Provider) zones(ctx context.Context) ([]dynv6.Zone, error) {
+	p.mu.RLock()
+	a, err := p.accounts, p.err
+	p.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	done, err := p.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	var o []dynv6.Zone
+	seen := make(map[string]bool)
+	for _, c := range a {
+		t := time.Now()
+		z, err := c.ZonesCtx(ctx)
+		p.observe(OpZones, t, err)
+		if err != nil {
+			return nil, c.rateLimitErr(err)
+		}
+		for i := range z {
+			n := zoneName(z[i].Name)
+			if !seen[n] {
+				seen[n] = true
+				o = append(o, z[i])
+			}
+		}
+	}
+	return o, nil
+}
+
+func (p *Provider) zone(ctx context.Context, zone string) (*dynv6.Zone, error) {
+	c, done, err := p.client(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	t := time.Now()
+	z, err := c.ZoneNameCtx(ctx, zone)
+	p.observe(OpZone, t, err)
+	return z, c.rateLimitErr(err)
+}
+
+func (p *Provider) zoneUpd(ctx context.Context, zone string, z *dynv6.Zone, zr *dynv6.ZoneReq) (*dynv6.Zone, error) {
+	if p.DryRun {
+		p.dryRun(AuditZoneUpdate, zone, string(z.ID), nil)
+		o := *z
+		if zr.IPv4Address != `` {
+			o.IPv4Address = zr.IPv4Address
+		}
+		if zr.IPv6Prefix != `` {
+			o.IPv6Prefix = zr.IPv6Prefix
+		}
+		return &o, nil
+	}
+	c, done, err := p.client(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	t := time.Now()
+	o, err := c.ZoneUpdCtx(ctx, string(z.ID), zr)
+	p.observe(OpZoneUpdate, t, err)
+	p.audit(AuditZoneUpdate, zone, string(z.ID), nil, err)
+	return o, c.rateLimitErr(err)
+}
+
+func (p *Provider) zoneDel(ctx context.Context, zone string, z *dynv6.Zone) error {
+	if p.DryRun {
+		p.dryRun(AuditZoneDelete, zone, string(z.ID), nil)
+		return nil
+	}
+	c, done, err := p.client(ctx, zone)
+	if err != nil {
+		return err
+	}
+	defer done()
+	t := time.Now()
+	err = c.ZoneDelCtx(ctx, string(z.ID))
+	p.observe(OpZoneDelete, t, err)
+	p.uncache(zone)
+	p.audit(AuditZoneDelete, zone, string(z.ID), nil, err)
+	return c.rateLimitErr(err)
+}
+
+// records returns the zone and all of its records.
+func (p *Provider) records(ctx context.Context, zone string) (*dynv6.Zone, []dynv6.Record, error) {
+	z, err := p.zone(ctx, zone)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := p.recordsIn(ctx, zone, z)
+	if err != nil {
+		return nil, nil, err
+	}
+	return z, r, nil
+}
+
+// recordsIn returns the records of the resolved zone z.
+func (p *Provider) recordsIn(ctx context.Context, zone string, z *dynv6.Zone) ([]dynv6.Record, error) {
+	c, done, err := p.client(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	t := time.Now()
+	r, err := c.RecordsCtx(ctx, string(z.ID))
+	p.observe(OpRecords, t, err)
+	return r, c.rateLimitErr(err)
+}
+
+func (p *Provider) recordAdd(ctx context.Context, zone string, z *dynv6.Zone, lr *libdns.RR, dr *dynv6.RecordReq) (*dynv6.Record, error) {
+	if p.DryRun {
+		p.dryRun(AuditCreate, zone, ``, lr)
+		return dryRecord(dr), nil
+	}
+	c, done, err := p.client(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	t := time.Now()
+	o, err := c.RecordAddCtx(ctx, string(z.ID), dr)
+	p.observe(OpCreate, t, err)
+	id := ``
+	if err == nil {
+		id = string(o.ID)
+		p.adopt(zone, id)
+	}
+	p.audit(AuditCreate, zone, id, lr, err)
+	return o, c.rateLimitErr(err)
+}
+
+func (p *Provider) recordUpd(ctx context.Context, zone string, z *dynv6.Zone, id string, lr *libdns.RR, dr *dynv6.RecordReq) (*dynv6.Record, error) {
+	if p.DryRun {
+		p.dryRun(AuditUpdate, zone, id, lr)
+		return dryRecord(dr), nil
+	}
+	c, done, err := p.client(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	t := time.Now()
+	o, err := c.RecordUpdCtx(ctx, string(z.ID), id, dr)
+	p.observe(OpUpdate, t, err)
+	p.audit(AuditUpdate, zone, id, lr, err)
+	return o, c.rateLimitErr(err)
+}
+
+func (p *Provider) recordDel(ctx context.Context, zone string, z *dynv6.Zone, id string, lr *libdns.RR) error {
+	if p.DryRun {
+		p.dryRun(AuditDelete, zone, id, lr)
+		return nil
+	}
+	c, done, err := p.client(ctx, zone)
+	if err != nil {
+		return err
+	}
+	defer done()
+	t := time.Now()
+	err = c.RecordDelCtx(ctx, string(z.ID), id)
+	p.observe(OpDelete, t, err)
+	p.audit(AuditDelete, zone, id, lr, err)
+	return c.rateLimitErr(err)
+}
@@ -0,0 +1,64 @@
+package libdynv6
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ZxwyProject/dynv6"
+	"github.com/libdns/libdns"
+)
+
+// Audit operations.
+const (
+	AuditCreate = `create`
+	AuditUpdate = `update`
+	AuditDelete = `delete`
+
+	AuditZoneUpdate = `zone_update`
+	AuditZoneDelete = `zone_delete`
+)
+
+// AuditEntry describes a single record mutation performed by the [Provider].
+type AuditEntry struct {
+	Time   time.Time `json:"time"`
+	Op     string    `json:"op"` // AuditCreate, AuditUpdate, AuditDelete, ...
+	Zone   string    `json:"zone"`
+	ID     string    `json:"id,omitempty"` // dynv6 record or zone ID, if known
+	Record libdns.RR `json:"record"`       // zero for zone operations
+	OK     bool      `json:"ok"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// audit records a mutation to the configured audit writer and callback.
+func (p *Provider) audit(op, zone, id string, r *libdns.RR, err error) {
+	if p.Audit == nil && p.AuditFunc == nil {
+		return
+	}
+	e := AuditEntry{
+		Time: time.Now().UTC(),
+		Op:   op,
+		Zone: zone,
+		ID:   id,
+		OK:   err == nil,
+	}
+	if r != nil {
+		e.Record = *r
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+
+	p.am.Lock()
+	defer p.am.Unlock()
+
+	if p.AuditFunc != nil {
+		p.AuditFunc(e)
+	}
+	if p.Audit != nil {
+		b, _ := json.Marshal(&e)
+		b = append(b, '\n')
+		if _, err := p.Audit.Write(b); err != nil && dynv6.Debug {
+			dynv6.DbgLog.Println(`[Dynv6-debug/libdns] audit:`, err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package libdynv6
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ZxwyProject/dynv6"
+	"github.com/libdns/libdns"
+)
+
+// CAAFlagCritical is the issuer critical flag of CAA records. The other
+// flag bits are reserved and must be zero, see RFC 8659 section 4.1.
+const CAAFlagCritical = 128
+
+// CAATags are the CAA property tags known to this package, in lowercase;
+// tags are matched case-insensitively. A record with an unknown tag and the
+// critical flag forbids issuance by every CA, so writing one is rejected.
+var CAATags = map[string]bool{
+	`issue`:        true, // RFC 8659
+	`issuewild`:    true, // RFC 8659
+	`iodef`:        true, // RFC 8659
+	`issuemail`:    true, // RFC 9495
+	`contactemail`: true, // CA/Browser Forum
+	`contactphone`: true, // CA/Browser Forum
+}
+
+// CAA is a CAA record with its flags decoded.
+type CAA struct {
+	Name     string
+	TTL      time.Duration
+	Critical bool   // issuer critical flag
+	Tag      string // property tag, e.g. "issue"
+	Value    string // property value, without quotes
+}
+
+// RR implements [libdns.Record].
+func (c CAA) RR() libdns.RR {
+	var flags uint8
+	if c.Critical {
+		flags = CAAFlagCritical
+	}
+	return libdns.RR{
+		Name: c.Name,
+		TTL:  c.TTL,
+		Type: dynv6.RT_CAA,
+		Data: fmt.Sprintf(`%d %s %q`, flags, c.Tag, c.Value),
+	}
+}
+
+// Known reports whether the tag of c is one of CAATags.
+func (c CAA) Known() bool { return CAATags[strings.ToLower(c.Tag)] }
+
+// ParseCAA decodes a CAA record, e.g. one returned by the [Provider].
+// It fails for records which could not be written, e.g. with reserved
+// flags set or an unknown critical tag.
+func ParseCAA(r libdns.Record) (CAA, error) {
+	rr := r.RR()
+	if rr.Type != dynv6.RT_CAA {
+		return CAA{}, fmt.Errorf(`%s is not a CAA record`, rr.Type)
+	}
+	q, err := recordFromLibdns(&rr)
+	if err != nil {
+		return CAA{}, err
+	}
+	return CAA{
+		Name:     rr.Name,
+		TTL:      rr.TTL,
+		Critical: q.Flags&CAAFlagCritical != 0,
+		Tag:      q.Tag,
+		Value:    q.Data,
+	}, nil
+}
+
+// caaCheck validates the flags and tag of a CAA record: reserved flags
+// must be zero, the tag must be 1 to 15 ASCII letters and digits, and an
+// unknown tag must not be critical.
+func caaCheck(flags uint8, tag string) error {
+	if flags&^CAAFlagCritical != 0 {
+		return fmt.Errorf(`invalid CAA flags %d: only the critical flag %d may be set`, flags, CAAFlagCritical)
+	}
+	if len(tag) == 0 || len(tag) > 15 {
+		return fmt.Errorf(`invalid CAA tag %q: must be 1 to 15 characters`, tag)
+	}
+	for i := 0; i < len(tag); i++ {
+		if c := tag[i] | 0x20; (c < 'a' || c > 'z') && (tag[i] < '0' || tag[i] > '9') {
+			return fmt.Errorf(`invalid CAA tag %q: must only contain letters and digits`, tag)
+		}
+	}
+	if flags&CAAFlagCritical != 0 && !CAATags[strings.ToLower(tag)] {
+		return fmt.Errorf(`CAA tag %q is unknown and critical, which forbids issuance by every CA`, tag)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package libdynv6
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// ErrConflict is returned if a zone was modified since it was read.
+var ErrConflict = errors.New(`zone modified concurrently`)
+
+// SetRecordsIfUnmodified is like [Provider.SetRecords], but fails with
+// ErrConflict if the zone was modified after since, usually the UpdatedAt
+// of an earlier [Provider.GetZone], so concurrent writers do not silently
+// overwrite each other.
+//
+// dynv6 only reports modification times for zones, not for single records,
+// so any change to the zone counts as a conflict. The check happens right
+// before writing and cannot rule out a concurrent write in between.
+func (p *Provider) SetRecordsIfUnmodified(ctx context.Context, zone string, records []libdns.Record, since time.Time) ([]libdns.Record, error) {
+	if since.IsZero() {
+		return nil, errors.New(`libdynv6: SetRecordsIfUnmodified needs a time`)
+	}
+	return p.setRecords(ctx, zone, records, since)
+}
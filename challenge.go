@@ -0,0 +1,141 @@
+package libdynv6
+
+import (
+	"context"
+
+	"github.com/ZxwyProject/dynv6"
+	"github.com/libdns/libdns"
+)
+
+// Fast path for short-lived ACME challenge records: the zone is resolved
+// once and cached, records are created without reading the zone first, and
+// the ID of each created record is remembered so it can be deleted directly.
+
+// zoneCached is like p.zone, but resolves each zone only once per account,
+// see [sharedClient].
+func (p *Provider) zoneCached(ctx context.Context, zone string) (*dynv6.Zone, error) {
+	c, err := p.lookup(zone)
+	if err != nil {
+		return nil, err
+	}
+	n := zoneName(zone)
+	if z := c.cachedZone(n); z != nil {
+		return z, nil
+	}
+
+	z, err := p.zone(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	c.cacheZone(n, z)
+	return z, nil
+}
+
+// uncache drops zone from the zone cache, e.g. after it was deleted.
+func (p *Provider) uncache(zone string) {
+	if c, err := p.lookup(zone); err == nil {
+		c.cacheZone(zoneName(zone), nil)
+	}
+}
+
+func challengeKey(zone string, r *libdns.TXT) string {
+	return zoneName(zone) + `/` + r.Name + `/` + r.Text
+}
+
+// challenge is a record created by AppendChallenge.
+type challenge struct {
+	id string
+	n  int // AppendChallenge calls not yet undone by DeleteChallenge
+}
+
+// AppendChallenge creates the TXT record r in zone without reading the
+// records of the zone first, so existing identical records are not detected.
+// The ID of the created record is remembered for [Provider.DeleteChallenge].
+//
+// Identical calls, e.g. from parallel ACME orders sharing an authorization,
+// share one record: only the first creates it, and it is deleted by the
+// last matching DeleteChallenge.
+func (p *Provider) AppendChallenge(ctx context.Context, zone string, r libdns.TXT) error {
+	p.o.Do(p.init)
+	unlock, err := p.lockZone(ctx, zone)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	k := challengeKey(zone, &r)
+	p.cm.Lock()
+	if c := p.chl[k]; c != nil {
+		c.n++
+		p.cm.Unlock()
+		return nil
+	}
+	p.cm.Unlock()
+
+	z, err := p.zoneCached(ctx, zone)
+	if err != nil {
+		return err
+	}
+	lr := r.RR()
+	dr, err := p.converter().FromLibdns(&lr)
+	if err != nil {
+		return err
+	}
+	o, err := p.recordAdd(ctx, zone, z, &lr, dr)
+	if err != nil {
+		p.uncache(zone)
+		return err
+	}
+
+	p.cm.Lock()
+	if p.chl == nil {
+		p.chl = make(map[string]*challenge)
+	}
+	p.chl[k] = &challenge{string(o.ID), 1}
+	p.cm.Unlock()
+	return nil
+}
+
+// DeleteChallenge deletes the TXT record r created by [Provider.AppendChallenge]
+// by its ID. Records not created by p are deleted like by [Provider.DeleteRecords].
+func (p *Provider) DeleteChallenge(ctx context.Context, zone string, r libdns.TXT) error {
+	p.o.Do(p.init)
+	unlock, err := p.lockZone(ctx, zone)
+	if err != nil {
+		return err
+	}
+
+	k := challengeKey(zone, &r)
+	p.cm.Lock()
+	c := p.chl[k]
+	if c != nil && c.n > 1 {
+		c.n--
+		p.cm.Unlock()
+		unlock()
+		return nil
+	}
+	if c != nil && c.id == `` {
+		delete(p.chl, k) // from a dry run
+	}
+	p.cm.Unlock()
+	if c == nil || c.id == `` {
+		unlock()
+		_, err = p.DeleteRecords(ctx, zone, []libdns.Record{r})
+		return err
+	}
+	defer unlock()
+
+	z, err := p.zoneCached(ctx, zone)
+	if err != nil {
+		return err
+	}
+	lr := r.RR()
+	if err = p.recordDel(ctx, zone, z, c.id, &lr); err != nil {
+		return err
+	}
+	p.disown(zone, c.id)
+	p.cm.Lock()
+	delete(p.chl, k)
+	p.cm.Unlock()
+	return nil
+}
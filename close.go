@@ -0,0 +1,83 @@
+package libdynv6
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrClosed is returned by the methods of a [Provider] after Close.
+var ErrClosed = errors.New(`provider closed`)
+
+// Close shuts p down: watchers of [Provider.WatchZone] stop, new API calls
+// fail with ErrClosed, and calls in flight are waited for until ctx is done.
+// Then the Audit writer is flushed if it has a Flush or Sync method, e.g. a
+// *bufio.Writer or an *os.File, and the clients shared with other Providers
+// are released. Updaters run with their own context and are not affected.
+// Calling Close again has no effect.
+func (p *Provider) Close(ctx context.Context) error {
+	p.o.Do(p.init)
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.quit)
+	var idle chan struct{}
+	if p.calls > 0 {
+		idle = make(chan struct{})
+		p.idle = idle
+	}
+	a := p.accounts
+	p.mu.Unlock()
+
+	var errs []error
+	if idle != nil {
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			errs = append(errs, ctx.Err())
+		}
+	}
+	if err := p.flushAudit(); err != nil {
+		errs = append(errs, err)
+	}
+	for _, c := range a {
+		releaseClient(c)
+	}
+	return errors.Join(errs...)
+}
+
+// enterLocked registers an API call in flight, to be ended by p.leave.
+// See p.begin.
+// p.mu must be held.
+func (p *Provider) enterLocked() error {
+	if p.closed {
+		return ErrClosed
+	}
+	p.calls++
+	return nil
+}
+
+// leave ends an API call registered by p.enterLocked.
+func (p *Provider) leave() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.calls--; p.calls == 0 && p.idle != nil {
+		close(p.idle)
+		p.idle = nil
+	}
+}
+
+// flushAudit flushes the Audit writer, if it supports it.
+func (p *Provider) flushAudit() error {
+	p.am.Lock()
+	defer p.am.Unlock()
+	switch w := p.Audit.(type) {
+	case interface{ Flush() error }:
+		return w.Flush()
+	case interface{ Sync() error }:
+		return w.Sync()
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ZxwyProject/libdynv6"
+	"github.com/libdns/libdns"
+)
+
+const shellHelp = `Commands:
+  zones                       list zones
+  use <zone>                  select a zone
+  ls                          list records of the zone
+  add <name> <type> <data>    create a record
+  edit <n> <data>             change the data of record n
+  rm <n>                      delete record n
+  help                        show this help
+  quit                        exit
+`
+
+// shell is the interactive record browser.
+type shell struct {
+	p    *libdynv6.Provider
+	in   *bufio.Scanner
+	out  io.Writer
+	zone string
+	recs []libdns.RR // as last listed
+}
+
+func interactive(ctx context.Context, p *libdynv6.Provider) error {
+	s := &shell{
+		p:   p,
+		in:  bufio.NewScanner(os.Stdin),
+		out: os.Stdout,
+	}
+	// show the API calls behind every change
+	p.AuditFunc = func(e libdynv6.AuditEntry) {
+		st := `ok`
+		if !e.OK {
+			st = e.Error
+		}
+		fmt.Fprintf(s.out, "  api: %s %s %s %s (%s)\n", e.Op, e.Record.Name, e.Record.Type, e.Record.Data, st)
+	}
+
+	fmt.Fprint(s.out, shellHelp)
+	for {
+		fmt.Fprintf(s.out, `dynv6 %s> `, s.zone)
+		if !s.in.Scan() {
+			return s.in.Err()
+		}
+		f := strings.Fields(s.in.Text())
+		if len(f) == 0 {
+			continue
+		}
+		if f[0] == `quit` || f[0] == `exit` {
+			return nil
+		}
+		if err := s.exec(ctx, f); err != nil {
+			fmt.Fprintln(s.out, `error:`, err)
+		}
+	}
+}
+
+func (s *shell) exec(ctx context.Context, f []string) error {
+	switch f[0] {
+	case `help`:
+		fmt.Fprint(s.out, shellHelp)
+		return nil
+	case `zones`:
+		return zones(ctx, s.p)
+	case `use`:
+		if len(f) != 2 {
+			return errUsage
+		}
+		s.zone = f[1]
+		return s.list(ctx)
+	}
+
+	if s.zone == `` {
+		return fmt.Errorf(`no zone selected, see "use"`)
+	}
+	switch f[0] {
+	case `ls`:
+		return s.list(ctx)
+
+	case `add`:
+		if len(f) < 4 {
+			return errUsage
+		}
+		rr := libdns.RR{Name: f[1], Type: f[2], Data: strings.Join(f[3:], ` `)}
+		if !s.confirm(`create ` + rr.Name + ` ` + rr.Type + ` ` + rr.Data) {
+			return nil
+		}
+		_, err := s.p.AppendRecords(ctx, s.zone, []libdns.Record{rr})
+		return err
+
+	case `edit`:
+		if len(f) < 3 {
+			return errUsage
+		}
+		i, err := s.index(f[1])
+		if err != nil {
+			return err
+		}
+		// replace the record within its RRset
+		old, data := s.recs[i], strings.Join(f[2:], ` `)
+		var set []libdns.Record
+		for j, r := range s.recs {
+			if r.Name != old.Name || r.Type != old.Type {
+				continue
+			}
+			if j == i {
+				r.Data = data
+			}
+			set = append(set, r)
+		}
+		pl, err := s.p.Plan(ctx, s.zone, set)
+		if err != nil {
+			return err
+		}
+		if !s.confirm(strings.TrimSpace(pl.String())) {
+			return nil
+		}
+		_, err = s.p.Apply(ctx, pl)
+		return err
+
+	case `rm`:
+		if len(f) != 2 {
+			return errUsage
+		}
+		i, err := s.index(f[1])
+		if err != nil {
+			return err
+		}
+		r := s.recs[i]
+		if !s.confirm(`delete ` + r.Name + ` ` + r.Type + ` ` + r.Data) {
+			return nil
+		}
+		_, err = s.p.DeleteRecords(ctx, s.zone, []libdns.Record{r})
+		return err
+
+	default:
+		return fmt.Errorf(`unknown command %q, see "help"`, f[0])
+	}
+}
+
+func (s *shell) list(ctx context.Context) error {
+	r, err := s.p.GetRecords(ctx, s.zone)
+	if err != nil {
+		return err
+	}
+	s.recs = s.recs[:0]
+	for i, x := range r {
+		rr := x.RR()
+		s.recs = append(s.recs, rr)
+		fmt.Fprintf(s.out, "%3d  %-24s %-6s %s\n", i+1, rr.Name, rr.Type, rr.Data)
+	}
+	return nil
+}
+
+func (s *shell) index(arg string) (int, error) {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 || n > len(s.recs) {
+		return 0, fmt.Errorf(`no record %s, see "ls"`, arg)
+	}
+	return n - 1, nil
+}
+
+func (s *shell) confirm(what string) bool {
+	fmt.Fprintf(s.out, "%s\nproceed? [y/N] ", what)
+	if !s.in.Scan() {
+		return false
+	}
+	a := strings.ToLower(strings.TrimSpace(s.in.Text()))
+	return a == `y` || a == `yes`
+}
@@ -0,0 +1,370 @@
+// Command dynv6ctl manages dynv6 zones and records from the command line.
+//
+// Usage:
+//
+//	dynv6ctl [flags] zones
+//	dynv6ctl [flags] records get <zone>
+//	dynv6ctl [flags] records add|set <zone> <name> <type> <data>
+//	dynv6ctl [flags] records delete <zone> <name> [type] [data]
+//	dynv6ctl [flags] export <zone>
+//	dynv6ctl [flags] import <zone> [file]
+//	dynv6ctl [flags] apply <state.yaml>
+//	dynv6ctl [flags] update <config.json>
+//	dynv6ctl [flags] webhook <addr> [zone...]
+//	dynv6ctl [flags] interactive
+//
+// The token is read from -token or the DYNV6_TOKEN environment variable.
+// Records are imported and exported as a JSON array of {name, type, ttl, data},
+// as an RFC 1035 zone file with -format zone, or exported as a desired state
+// for apply with -format yaml. With -format octodns, export writes and apply
+// reads OctoDNS zone files named after the zone, e.g. example.com.yaml.
+// With -listen, update serves /healthz, /readyz
+// and /metrics for supervisors; run as a systemd service, it supports
+// Type=notify and WatchdogSec=. webhook serves the external-dns webhook
+// provider API on addr, e.g. localhost:8888.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ZxwyProject/dynv6"
+	"github.com/ZxwyProject/libdynv6"
+	"github.com/ZxwyProject/libdynv6/externaldns"
+	"github.com/ZxwyProject/libdynv6/updater"
+	"github.com/libdns/libdns"
+)
+
+var errUsage = errors.New(`usage`)
+
+var (
+	format string
+	listen string
+)
+
+func main() {
+	token := flag.String(`token`, os.Getenv(`DYNV6_TOKEN`), `dynv6 HTTP token`)
+	debug := flag.Bool(`debug`, false, `log API calls`)
+	dry := flag.Bool(`dry-run`, false, `only print the changes which would be made`)
+	flag.StringVar(&format, `format`, `json`, `import/export format: json, zone, yaml or octodns (export and apply only)`)
+	flag.StringVar(&listen, `listen`, ``, `address to serve /healthz, /readyz and /metrics on in update mode, e.g. localhost:9090`)
+	flag.Usage = usage
+	flag.Parse()
+
+	dynv6.Debug = *debug
+	if *token == `` {
+		fmt.Fprintln(os.Stderr, `dynv6ctl: no token, set -token or DYNV6_TOKEN`)
+		os.Exit(2)
+	}
+	p := &libdynv6.Provider{Token: *token, DryRun: *dry}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	err := run(ctx, p, flag.Args())
+	if *dry {
+		for _, e := range p.DryRunOps() {
+			fmt.Fprintf(os.Stderr, "dry-run: %s %s %s %s %s\n", e.Op, e.Zone, e.Record.Name, e.Record.Type, e.Record.Data)
+		}
+	}
+	if err == errUsage {
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, `dynv6ctl:`, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprint(os.Stderr, `Usage:
+  dynv6ctl [flags] zones
+  dynv6ctl [flags] records get <zone>
+  dynv6ctl [flags] records add|set <zone> <name> <type> <data>
+  dynv6ctl [flags] records delete <zone> <name> [type] [data]
+  dynv6ctl [flags] export <zone>
+  dynv6ctl [flags] import <zone> [file]
+  dynv6ctl [flags] apply <state.yaml>
+  dynv6ctl [flags] update <config.json>
+  dynv6ctl [flags] webhook <addr> [zone...]
+  dynv6ctl [flags] interactive
+
+Flags:
+`)
+	flag.PrintDefaults()
+}
+
+func run(ctx context.Context, p *libdynv6.Provider, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	switch args[0] {
+	case `zones`:
+		return zones(ctx, p)
+	case `records`:
+		return records(ctx, p, args[1:])
+	case `export`:
+		if len(args) != 2 {
+			return errUsage
+		}
+		return export(ctx, p, args[1], os.Stdout)
+	case `import`:
+		if len(args) != 2 && len(args) != 3 {
+			return errUsage
+		}
+		in := os.Stdin
+		if len(args) == 3 {
+			f, err := os.Open(args[2])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			in = f
+		}
+		return imprt(ctx, p, args[1], in)
+	case `apply`:
+		if len(args) != 2 {
+			return errUsage
+		}
+		load := libdynv6.LoadDesiredStateFile
+		if format == `octodns` {
+			load = libdynv6.LoadOctoDNSFile
+		}
+		st, err := load(args[1])
+		if err != nil {
+			return err
+		}
+		r, err := p.ApplyDesiredState(ctx, st)
+		if err != nil {
+			return err
+		}
+		return printRecords(r)
+	case `update`:
+		if len(args) != 2 {
+			return errUsage
+		}
+		return runUpdater(ctx, p, args[1])
+	case `webhook`:
+		if len(args) < 2 {
+			return errUsage
+		}
+		return serveWebhook(ctx, p, args[1], args[2:])
+	case `interactive`, `i`:
+		return interactive(ctx, p)
+	default:
+		return errUsage
+	}
+}
+
+func zones(ctx context.Context, p *libdynv6.Provider) error {
+	z, err := p.ListZonesDetailed(ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tIPV4\tIPV6 PREFIX")
+	for _, x := range z {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", x.ID, x.Name, x.IPv4Address, x.IPv6Prefix)
+	}
+	return w.Flush()
+}
+
+func records(ctx context.Context, p *libdynv6.Provider, args []string) error {
+	if len(args) < 2 {
+		return errUsage
+	}
+	zone := args[1]
+
+	switch args[0] {
+	case `get`:
+		if len(args) != 2 {
+			return errUsage
+		}
+		r, err := p.GetRecords(ctx, zone)
+		if err != nil {
+			return err
+		}
+		return printRecords(r)
+
+	case `add`, `set`:
+		if len(args) != 5 {
+			return errUsage
+		}
+		in := []libdns.Record{libdns.RR{Name: args[2], Type: args[3], Data: args[4]}}
+		var r []libdns.Record
+		var err error
+		if args[0] == `add` {
+			r, err = p.AppendRecords(ctx, zone, in)
+		} else {
+			r, err = p.SetRecords(ctx, zone, in)
+		}
+		if err != nil {
+			return err
+		}
+		return printRecords(r)
+
+	case `delete`:
+		if len(args) < 3 || len(args) > 5 {
+			return errUsage
+		}
+		rr := libdns.RR{Name: args[2]}
+		if len(args) > 3 {
+			rr.Type = args[3]
+		}
+		if len(args) > 4 {
+			rr.Data = args[4]
+		}
+		r, err := p.DeleteRecords(ctx, zone, []libdns.Record{rr})
+		if err != nil {
+			return err
+		}
+		return printRecords(r)
+
+	default:
+		return errUsage
+	}
+}
+
+func printRecords(r []libdns.Record) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTTL\tTYPE\tDATA")
+	for _, x := range r {
+		rr := x.RR()
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", rr.Name, int(rr.TTL/time.Second), rr.Type, rr.Data)
+	}
+	return w.Flush()
+}
+
+// jsonRR is the import/export format of a record.
+type jsonRR struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	TTL  int    `json:"ttl,omitempty"` // seconds
+	Data string `json:"data"`
+}
+
+func export(ctx context.Context, p *libdynv6.Provider, zone string, w io.Writer) error {
+	switch format {
+	case `zone`:
+		return p.ExportZone(ctx, zone, w)
+	case `yaml`, `octodns`:
+		st, err := p.DumpDesiredState(ctx, zone)
+		if err != nil {
+			return err
+		}
+		if format == `octodns` {
+			return st.WriteOctoDNS(w)
+		}
+		return st.WriteYAML(w)
+	}
+	r, err := p.GetRecords(ctx, zone)
+	if err != nil {
+		return err
+	}
+	o := make([]jsonRR, len(r))
+	for i, x := range r {
+		rr := x.RR()
+		o[i] = jsonRR{rr.Name, rr.Type, int(rr.TTL / time.Second), rr.Data}
+	}
+	e := json.NewEncoder(w)
+	e.SetIndent(``, `  `)
+	return e.Encode(o)
+}
+
+func imprt(ctx context.Context, p *libdynv6.Provider, zone string, rd io.Reader) error {
+	if format == `zone` {
+		return imprtZone(ctx, p, zone, rd)
+	}
+	var in []jsonRR
+	if err := json.NewDecoder(rd).Decode(&in); err != nil {
+		return err
+	}
+	r := make([]libdns.Record, len(in))
+	for i, x := range in {
+		r[i] = libdns.RR{Name: x.Name, Type: x.Type, TTL: time.Duration(x.TTL) * time.Second, Data: x.Data}
+	}
+	r, err := p.SetRecords(ctx, zone, r)
+	if err != nil {
+		return err
+	}
+	return printRecords(r)
+}
+
+func runUpdater(ctx context.Context, p *libdynv6.Provider, config string) error {
+	b, err := os.ReadFile(config)
+	if err != nil {
+		return err
+	}
+	u := updater.Updater{Provider: p}
+	if err = json.Unmarshal(b, &u); err != nil {
+		return fmt.Errorf(`%s: %v`, config, err)
+	}
+	if listen != `` {
+		srv := &http.Server{Addr: listen, Handler: u.Handler(), ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+				fmt.Fprintln(os.Stderr, `dynv6ctl:`, err)
+			}
+		}()
+		defer srv.Close()
+	}
+	if err = u.Run(ctx); err == context.Canceled {
+		return nil
+	}
+	return err
+}
+
+func serveWebhook(ctx context.Context, p *libdynv6.Provider, addr string, zones []string) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           &externaldns.Handler{Provider: p, Zones: zones},
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		srv.Shutdown(context.Background())
+	}()
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func imprtZone(ctx context.Context, p *libdynv6.Provider, zone string, rd io.Reader) error {
+	res, err := p.ImportZone(ctx, zone, rd)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tDATA\tRESULT")
+	for _, x := range res {
+		s := `ok`
+		switch {
+		case x.Skipped:
+			s = `skipped`
+		case x.Err != nil:
+			s = x.Err.Error()
+			failed++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", x.Record.Name, x.Record.Type, x.Record.Data, s)
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf(`%d records failed`, failed)
+	}
+	return nil
+}
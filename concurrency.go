@@ -0,0 +1,61 @@
+package libdynv6
+
+import "context"
+
+// semaphore limits the number of API calls in flight.
+type semaphore chan struct{}
+
+type concurrencyKey struct{}
+
+// WithConcurrency returns a context limiting the API calls made with it,
+// and contexts derived from it, to n at a time, in addition to
+// [Provider.MaxConcurrentRequests]. Worker pools such as the one of the
+// updater package use at most n workers. n <= 0 means no limit.
+func WithConcurrency(ctx context.Context, n int) context.Context {
+	if n <= 0 {
+		return context.WithValue(ctx, concurrencyKey{}, semaphore(nil))
+	}
+	return context.WithValue(ctx, concurrencyKey{}, make(semaphore, n))
+}
+
+// Concurrency returns the limit set by [WithConcurrency], or 0 if none.
+func Concurrency(ctx context.Context) int {
+	s, _ := ctx.Value(concurrencyKey{}).(semaphore)
+	return cap(s)
+}
+
+// begin registers an API call in flight, see [Provider.Close], and waits
+// until the concurrency limits of p and ctx allow it. The returned function
+// ends the call.
+func (p *Provider) begin(ctx context.Context) (func(), error) {
+	p.mu.Lock()
+	err := p.enterLocked()
+	ps := p.sem
+	p.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	cs, _ := ctx.Value(concurrencyKey{}).(semaphore)
+	var held []semaphore
+	done := func() {
+		for _, s := range held {
+			<-s
+		}
+		p.leave()
+	}
+	// Always in this order, so calls never wait for each other crosswise.
+	for _, s := range []semaphore{cs, ps} {
+		if s == nil {
+			continue
+		}
+		select {
+		case s <- struct{}{}:
+			held = append(held, s)
+		case <-ctx.Done():
+			done()
+			return nil, ctx.Err()
+		}
+	}
+	return done, nil
+}
@@ -0,0 +1,65 @@
+package libdynv6
+
+import (
+	"github.com/ZxwyProject/dynv6"
+	"github.com/libdns/libdns"
+)
+
+// RecordConverter converts records between dynv6 and libdns notation.
+// Set [Provider.Converter] to handle record types this package does not
+// know, or to change how known ones are represented. Implementations
+// usually embed [DefaultConverter] and fall back to it.
+type RecordConverter interface {
+	// ToLibdns converts a record read from dynv6. The TTL of the result
+	// is replaced by the one of the Provider, see [Provider.TTL].
+	ToLibdns(r *dynv6.Record) libdns.RR
+
+	// FromLibdns converts a record to write to dynv6. It returns
+	// ErrUnsupportedType for record types it cannot convert.
+	FromLibdns(r *libdns.RR) (*dynv6.RecordReq, error)
+}
+
+// DefaultConverter is the [RecordConverter] used unless [Provider.Converter]
+// is set. Records of types it does not know are passed through as is when
+// read and rejected with ErrUnsupportedType when written.
+type DefaultConverter struct{}
+
+// ToLibdns implements [RecordConverter].
+func (DefaultConverter) ToLibdns(r *dynv6.Record) libdns.RR {
+	return recordToLibdns(r).RR()
+}
+
+// FromLibdns implements [RecordConverter].
+func (DefaultConverter) FromLibdns(r *libdns.RR) (*dynv6.RecordReq, error) {
+	return recordFromLibdns(r)
+}
+
+// converter returns the configured converter.
+func (p *Provider) converter() RecordConverter {
+	if p.Converter != nil {
+		return p.Converter
+	}
+	return DefaultConverter{}
+}
+
+// convertRecord converts r with c, keeping its ID and raw record.
+func convertRecord(c RecordConverter, r *dynv6.Record) Record {
+	rr := c.ToLibdns(r)
+	raw := *r
+	o := Record{
+		Name: rr.Name,
+		TTL:  rr.TTL,
+		Type: rr.Type,
+		Data: rr.Data,
+		ID:   string(r.ID),
+		raw:  &raw,
+	}
+	if r.ExpandedData != `` && r.ExpandedData != r.Data {
+		x := *r
+		x.Data = x.ExpandedData
+		if d := c.ToLibdns(&x).Data; d != o.Data {
+			o.Expanded = d
+		}
+	}
+	return o
+}
@@ -0,0 +1,43 @@
+package libdynv6_test
+
+import (
+	"testing"
+
+	"github.com/ZxwyProject/dynv6"
+	"github.com/ZxwyProject/libdynv6"
+	"github.com/ZxwyProject/libdynv6/dynv6test"
+	"github.com/libdns/libdns"
+)
+
+// FuzzRecordFromLibdns checks that every record accepted for writing reads
+// back as a record which is written the same way.
+func FuzzRecordFromLibdns(f *testing.F) {
+	for _, c := range dynv6test.ConversionCases {
+		f.Add(c.RR.Name, c.RR.Type, c.RR.Data)
+	}
+	var c libdynv6.DefaultConverter
+	f.Fuzz(func(t *testing.T, name, typ, data string) {
+		rr := libdns.RR{Name: name, Type: typ, Data: data}
+		q, err := c.FromLibdns(&rr)
+		if err != nil {
+			return
+		}
+		back := c.ToLibdns(&dynv6.Record{
+			Type:     q.Type,
+			Name:     q.Name,
+			Data:     q.Data,
+			Priority: q.Priority,
+			Flags:    q.Flags,
+			Tag:      q.Tag,
+			Weight:   q.Weight,
+			Port:     q.Port,
+		})
+		q2, err := c.FromLibdns(&back)
+		if err != nil {
+			t.Fatalf(`%q read back from %+v fails to convert: %v`, back.Data, *q, err)
+		}
+		if *q2 != *q {
+			t.Fatalf("%q %q %q converts to\n%+v, read back as %q and converted to\n%+v", name, typ, data, *q, back.Data, *q2)
+		}
+	})
+}
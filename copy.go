@@ -0,0 +1,47 @@
+package libdynv6
+
+import (
+	"context"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// CopyRecords duplicates the records of srcZone matching f into dstZone,
+// see [Provider.AppendRecords], and returns the records created.
+//
+// If one zone is a subdomain of the other, e.g. when splitting
+// sub.example.com out of example.com, names are rewritten to stay the same
+// absolute names and records outside of dstZone are not copied. Otherwise
+// relative names are kept, e.g. when staging changes in a copy of a zone.
+// f is applied to the names in srcZone. Apex NS records are never copied.
+func (p *Provider) CopyRecords(ctx context.Context, srcZone, dstZone string, f RecordFilter) ([]libdns.Record, error) {
+	r, err := p.GetRecordsFiltered(ctx, srcZone, f)
+	if err != nil {
+		return nil, err
+	}
+	src, dst := zoneName(srcZone), zoneName(dstZone)
+	nested := src != dst && (strings.HasSuffix(dst, `.`+src) || strings.HasSuffix(src, `.`+dst))
+
+	in := make([]libdns.Record, 0, len(r))
+	for _, x := range r {
+		rr := x.RR()
+		if nested {
+			abs := libdns.AbsoluteName(rr.Name, src)
+			if abs != dst && !strings.HasSuffix(abs, `.`+dst) {
+				continue
+			}
+			if rr.Name = libdns.RelativeName(abs, dst); rr.Name == `@` {
+				rr.Name = `` // as dynv6 names the apex
+			}
+		}
+		if rr.Type == rtNS && (rr.Name == `` || rr.Name == `@`) {
+			continue
+		}
+		in = append(in, rr)
+	}
+	if len(in) == 0 {
+		return nil, nil
+	}
+	return p.AppendRecords(ctx, dstZone, in)
+}
@@ -0,0 +1,221 @@
+package libdynv6
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ZxwyProject/dynv6"
+	"github.com/libdns/libdns"
+)
+
+// RecordRef addresses a single record by the dynv6 IDs of its zone and of
+// itself. Unlike name and type, the IDs never change while the record
+// exists, also not when it is updated, so a RecordRef can be kept as the
+// identity of a resource, e.g. in Terraform or Pulumi state.
+type RecordRef struct {
+	ZoneID   string `json:"zone_id"`
+	RecordID string `json:"record_id"`
+}
+
+// String returns "zoneID/recordID", the form read by [ParseRecordRef].
+func (r RecordRef) String() string { return r.ZoneID + `/` + r.RecordID }
+
+// ParseRecordRef parses "zoneID/recordID", e.g. an import ID.
+func ParseRecordRef(s string) (RecordRef, error) {
+	z, r, ok := strings.Cut(s, `/`)
+	if !ok || z == `` || r == `` || strings.Contains(r, `/`) {
+		return RecordRef{}, fmt.Errorf(`libdynv6: invalid record reference %q, want zoneID/recordID`, s)
+	}
+	return RecordRef{z, r}, nil
+}
+
+// Resources manages zones and records one at a time by their IDs, as
+// resource-based infrastructure tools do: there is no matching by name,
+// type or data, and no RRset semantics. Reads of records or zones which no
+// longer exist fail with [ErrRecordNotFound] or [ErrZoneNotFound], so the
+// caller can drop them from its state. OwnRecordsOnly does not apply, since
+// every record is addressed explicitly, but OwnerID does.
+type Resources struct {
+	p *Provider
+}
+
+// Resources returns the ID-based CRUD interface of p.
+func (p *Provider) Resources() *Resources {
+	return &Resources{p}
+}
+
+// ZoneID returns the ID of the zone name, see [Provider.ZoneID].
+func (s *Resources) ZoneID(ctx context.Context, name string) (string, error) {
+	return s.p.ZoneID(ctx, name)
+}
+
+// Zone returns the zone with the ID zoneID, or [ErrZoneNotFound].
+func (s *Resources) Zone(ctx context.Context, zoneID string) (*ZoneInfo, error) {
+	s.p.o.Do(s.p.init)
+	z, err := s.p.zoneByID(ctx, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	i := zoneInfo(z)
+	return &i, nil
+}
+
+// Create creates r in the zone zoneID and returns its reference and the
+// record as stored by dynv6. r.Name is relative to the zone. Existing
+// records are never changed or matched, so creating the same record twice
+// yields two records.
+func (s *Resources) Create(ctx context.Context, zoneID string, r libdns.Record) (RecordRef, Record, error) {
+	p := s.p
+	p.o.Do(p.init)
+	z, err := p.zoneByID(ctx, zoneID)
+	if err != nil {
+		return RecordRef{}, Record{}, err
+	}
+	unlock, err := p.lockZone(ctx, z.Name)
+	if err != nil {
+		return RecordRef{}, Record{}, err
+	}
+	defer unlock()
+
+	lr := r.RR()
+	dr, err := p.converter().FromLibdns(&lr)
+	if err != nil {
+		return RecordRef{}, Record{}, err
+	}
+	var o *dynv6.Record
+	c := []Change{{Type: ChangeCreate, Record: lr}}
+	_, err = p.guarded(ctx, z.Name, z, nil, c, func() ([]Change, error) {
+		if o, err = p.recordAdd(ctx, z.Name, z, &lr, dr); err != nil {
+			return nil, err
+		}
+		return c, nil
+	})
+	if err != nil {
+		return RecordRef{}, Record{}, err
+	}
+	return RecordRef{zoneID, string(o.ID)}, p.libdnsRecord(o), nil
+}
+
+// Read returns the record ref, or [ErrRecordNotFound] if it was deleted.
+func (s *Resources) Read(ctx context.Context, ref RecordRef) (Record, error) {
+	p := s.p
+	p.o.Do(p.init)
+	z, err := p.zoneByID(ctx, ref.ZoneID)
+	if err != nil {
+		return Record{}, err
+	}
+	d, err := p.recordByID(ctx, z, ref.RecordID)
+	if err != nil {
+		return Record{}, err
+	}
+	return p.libdnsRecord(d), nil
+}
+
+// Update replaces the record ref by r in place, keeping its ID, and returns
+// the record as stored by dynv6.
+func (s *Resources) Update(ctx context.Context, ref RecordRef, r libdns.Record) (Record, error) {
+	p := s.p
+	p.o.Do(p.init)
+	z, err := p.zoneByID(ctx, ref.ZoneID)
+	if err != nil {
+		return Record{}, err
+	}
+	unlock, err := p.lockZone(ctx, z.Name)
+	if err != nil {
+		return Record{}, err
+	}
+	defer unlock()
+	d, err := p.recordByID(ctx, z, ref.RecordID)
+	if err != nil {
+		return Record{}, err
+	}
+
+	lr := r.RR()
+	dr, err := p.converter().FromLibdns(&lr)
+	if err != nil {
+		return Record{}, err
+	}
+	var o *dynv6.Record
+	old := p.libdnsRecord(d).RR()
+	c := []Change{{Type: ChangeUpdate, Record: lr, Old: &old, ID: ref.RecordID}}
+	_, err = p.guarded(ctx, z.Name, z, nil, c, func() ([]Change, error) {
+		if o, err = p.recordUpd(ctx, z.Name, z, ref.RecordID, &lr, dr); err != nil {
+			return nil, err
+		}
+		return c, nil
+	})
+	if err != nil {
+		return Record{}, err
+	}
+	x := p.libdnsRecord(o)
+	x.ID = ref.RecordID
+	return x, nil
+}
+
+// Delete deletes the record ref. Deleting a record or zone which no longer
+// exists is not an error, so a delete can be retried safely.
+func (s *Resources) Delete(ctx context.Context, ref RecordRef) error {
+	p := s.p
+	p.o.Do(p.init)
+	z, err := p.zoneByID(ctx, ref.ZoneID)
+	if err == ErrZoneNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	unlock, err := p.lockZone(ctx, z.Name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	d, err := p.recordByID(ctx, z, ref.RecordID)
+	if err == ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	lr := p.libdnsRecord(d).RR()
+	c := []Change{{Type: ChangeDelete, Record: lr, ID: ref.RecordID}}
+	_, err = p.guarded(ctx, z.Name, z, nil, c, func() ([]Change, error) {
+		if err := p.recordDel(ctx, z.Name, z, ref.RecordID, &lr); err != nil {
+			return nil, err
+		}
+		return c, nil
+	})
+	if err != nil {
+		return err
+	}
+	p.disown(z.Name, ref.RecordID)
+	return nil
+}
+
+// zoneByID looks up the zone id in the zone list.
+func (p *Provider) zoneByID(ctx context.Context, id string) (*dynv6.Zone, error) {
+	z, err := p.zones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range z {
+		if string(z[i].ID) == id {
+			return &z[i], nil
+		}
+	}
+	return nil, ErrZoneNotFound
+}
+
+// recordByID returns the record id of the resolved zone z.
+func (p *Provider) recordByID(ctx context.Context, z *dynv6.Zone, id string) (*dynv6.Record, error) {
+	r, err := p.recordsIn(ctx, z.Name, z)
+	if err != nil {
+		return nil, err
+	}
+	for i := range r {
+		if string(r[i].ID) == id {
+			return &r[i], nil
+		}
+	}
+	return nil, ErrRecordNotFound
+}
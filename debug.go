@@ -0,0 +1,97 @@
+package libdynv6
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/ZxwyProject/dynv6"
+)
+
+const redacted = `[REDACTED]`
+
+// dumpTransport writes full HTTP requests and responses to [dynv6.DbgLog].
+type dumpTransport struct {
+	next http.RoundTripper
+}
+
+func (t *dumpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var b strings.Builder
+	b.WriteString(req.Method + ` ` + req.URL.String() + "\n")
+	dumpHeader(&b, req.Header)
+
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		dumpBody(&b, body)
+	}
+	dynv6.DbgLog.Println("[Dynv6-debug/libdns] >>>\n" + b.String())
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		dynv6.DbgLog.Println(`[Dynv6-debug/libdns] <<<`, err)
+		return nil, err
+	}
+
+	b.Reset()
+	b.WriteString(resp.Proto + ` ` + resp.Status + "\n")
+	dumpHeader(&b, resp.Header)
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	dumpBody(&b, body)
+	dynv6.DbgLog.Println("[Dynv6-debug/libdns] <<<\n" + b.String())
+
+	return resp, nil
+}
+
+func dumpHeader(b *strings.Builder, h http.Header) {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := strings.Join(h[k], `, `)
+		switch http.CanonicalHeaderKey(k) {
+		case `Authorization`, `Cookie`, `Set-Cookie`:
+			v = redacted
+		}
+		b.WriteString(k + `: ` + v + "\n")
+	}
+}
+
+func dumpBody(b *strings.Builder, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+	b.WriteByte('\n')
+
+	var o bytes.Buffer
+	if json.Indent(&o, body, ``, `  `) == nil {
+		b.Write(o.Bytes())
+	} else {
+		b.Write(body)
+	}
+	b.WriteByte('\n')
+}
+
+// transport returns the underlying transport of c, never nil.
+func transport(c *http.Client) http.RoundTripper {
+	if c.Transport != nil {
+		return c.Transport
+	}
+	return http.DefaultTransport
+}
@@ -0,0 +1,74 @@
+package libdynv6
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// DelegateSubzone delegates child to nameservers by creating NS records in parentZone.
+//
+// child may be relative to parentZone ("sub") or fully qualified ("sub.example.com.").
+// nameservers are fully qualified host names, with or without the trailing dot.
+// glue optionally maps nameservers inside parentZone to their addresses, for which
+// A/AAAA glue records are created. Existing records are never changed, see
+// [Provider.AppendRecords]. It returns the records which were created.
+func (p *Provider) DelegateSubzone(ctx context.Context, parentZone, child string, nameservers []string, glue map[string][]netip.Addr) ([]libdns.Record, error) {
+	name, ok := subName(child, parentZone)
+	if !ok || name == `@` {
+		return nil, fmt.Errorf(`%s is not a subzone of %s`, child, parentZone)
+	}
+	if len(nameservers) == 0 {
+		return nil, fmt.Errorf(`no nameservers for %s`, child)
+	}
+
+	r := make([]libdns.Record, 0, len(nameservers))
+	for _, ns := range nameservers {
+		if !strings.HasSuffix(ns, `.`) {
+			// host names, never relative to parentZone
+			ns += `.`
+		}
+		r = append(r, libdns.NS{
+			Name:   name,
+			TTL:    ttl,
+			Target: ns,
+		})
+	}
+	for ns, addrs := range glue {
+		gn, ok := subName(ns, parentZone)
+		if !ok {
+			return nil, fmt.Errorf(`glue for %s outside of %s`, ns, parentZone)
+		}
+		for _, a := range addrs {
+			r = append(r, libdns.Address{
+				Name: gn,
+				TTL:  ttl,
+				IP:   a,
+			})
+		}
+	}
+	return p.AppendRecords(ctx, parentZone, r)
+}
+
+// subName returns name relative to zone. Relative names are returned as-is,
+// fully qualified names must be within zone.
+func subName(name, zone string) (string, bool) {
+	if name == `` {
+		return ``, false
+	}
+	if !strings.HasSuffix(name, `.`) && !strings.HasSuffix(strings.ToLower(name), `.`+zoneName(zone)) {
+		// relative
+		return name, true
+	}
+	n, z := zoneName(name), zoneName(zone)
+	if n == z {
+		return `@`, true
+	}
+	if !strings.HasSuffix(n, `.`+z) {
+		return ``, false
+	}
+	return strings.TrimSuffix(n, `.`+z), true
+}
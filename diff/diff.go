@@ -0,0 +1,128 @@
+// Package diff computes the record changes turning one set of libdns
+// records into another, as used by the Plan and Sync features of libdynv6.
+//
+// Records are compared by name, type (case-insensitive) and data; TTLs are
+// ignored. Callers comparing data in different notations should normalize
+// it first.
+package diff
+
+import (
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// Kind is the kind of an Op.
+type Kind string
+
+// Kinds of an Op.
+const (
+	Create Kind = `create`
+	Update Kind = `update`
+	Delete Kind = `delete`
+)
+
+// Op is a single change.
+type Op struct {
+	Kind   Kind
+	Record libdns.RR  // desired record, or the current record to delete
+	Old    *libdns.RR // current record replaced by an Update
+
+	From int // index in current of the updated or deleted record, else -1
+	To   int // index in desired of the created or updated record, else -1
+}
+
+// Diff returns the changes turning current into desired as a whole:
+// records only in desired are created, records only in current deleted.
+// Within an RRset (name and type), leftover records are paired into updates
+// so the RRset never becomes empty in between.
+func Diff(current, desired []libdns.Record) []Op {
+	return diff(current, desired, true)
+}
+
+// RRsets is like Diff, but only RRsets present in desired are changed, as
+// by [libdns.RecordSetter]. Other records in current are left alone.
+func RRsets(current, desired []libdns.Record) []Op {
+	return diff(current, desired, false)
+}
+
+type key struct{ name, typ string }
+
+// keyOf returns the RRset of rr. The apex may be named "@" or "".
+func keyOf(rr *libdns.RR) key {
+	name := rr.Name
+	if name == `@` {
+		name = ``
+	}
+	return key{name, strings.ToUpper(rr.Type)}
+}
+
+func diff(current, desired []libdns.Record, all bool) []Op {
+	var keys []key
+	want := make(map[key][]int)
+	wrr := make([]libdns.RR, len(desired))
+	for i, d := range desired {
+		wrr[i] = d.RR()
+		k := keyOf(&wrr[i])
+		if _, ok := want[k]; !ok {
+			keys = append(keys, k)
+		}
+		want[k] = append(want[k], i)
+	}
+
+	have := make(map[key][]int)
+	crr := make([]libdns.RR, len(current))
+	for i, c := range current {
+		crr[i] = c.RR()
+		k := keyOf(&crr[i])
+		if _, ok := want[k]; !ok {
+			if !all {
+				continue
+			}
+			keys = append(keys, k)
+			want[k] = nil
+		}
+		have[k] = append(have[k], i)
+	}
+
+	var o []Op
+	for _, k := range keys {
+		o = append(o, rrset(crr, have[k], wrr, want[k])...)
+	}
+	return o
+}
+
+// rrset computes the changes turning the records h of current into w of desired.
+func rrset(current []libdns.RR, h []int, desired []libdns.RR, w []int) []Op {
+	used := make([]bool, len(h))
+	var rest []int
+
+next:
+	for _, i := range w {
+		for j, c := range h {
+			if !used[j] && current[c].Data == desired[i].Data {
+				used[j] = true
+				continue next
+			}
+		}
+		rest = append(rest, i)
+	}
+
+	var o []Op
+	for j, c := range h {
+		if used[j] {
+			continue
+		}
+		old := current[c]
+		if len(rest) > 0 {
+			o = append(o, Op{Kind: Update, Record: desired[rest[0]], Old: &old, From: c, To: rest[0]})
+			rest = rest[1:]
+		} else {
+			o = append(o, Op{Kind: Delete, Record: old, From: c, To: -1})
+		}
+	}
+	for _, i := range rest {
+		o = append(o, Op{Kind: Create, Record: desired[i], From: -1, To: i})
+	}
+	return o
+}
@@ -0,0 +1,132 @@
+package diff
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func rr(name, typ, data string) libdns.Record {
+	return libdns.RR{Name: name, Type: typ, Data: data, TTL: time.Minute}
+}
+
+// ops formats o as "kind name type data from to", with the old data of updates.
+func ops(o []Op) []string {
+	s := make([]string, len(o))
+	for i, x := range o {
+		f := []string{string(x.Kind), x.Record.Name, x.Record.Type, x.Record.Data, strconv.Itoa(x.From), strconv.Itoa(x.To)}
+		if x.Old != nil {
+			f = append(f, `old=`+x.Old.Data)
+		}
+		s[i] = strings.Join(f, ` `)
+	}
+	return s
+}
+
+func TestDiff(t *testing.T) {
+	for _, c := range []struct {
+		name             string
+		current, desired []libdns.Record
+		diff, rrsets     []string
+	}{
+		{name: `empty`},
+		{
+			name:    `unchanged`,
+			current: []libdns.Record{rr(`www`, `A`, `192.0.2.1`)},
+			desired: []libdns.Record{libdns.RR{Name: `www`, Type: `a`, Data: `192.0.2.1`, TTL: time.Hour}},
+		},
+		{
+			name:    `apex`,
+			current: []libdns.Record{rr(`@`, `A`, `192.0.2.1`)},
+			desired: []libdns.Record{rr(``, `A`, `192.0.2.1`)},
+		},
+		{
+			name:    `add`,
+			desired: []libdns.Record{rr(`www`, `A`, `192.0.2.1`), rr(`www`, `AAAA`, `2001:db8::1`)},
+			diff:    []string{`create www A 192.0.2.1 -1 0`, `create www AAAA 2001:db8::1 -1 1`},
+			rrsets:  []string{`create www A 192.0.2.1 -1 0`, `create www AAAA 2001:db8::1 -1 1`},
+		},
+		{
+			name:    `delete`,
+			current: []libdns.Record{rr(`www`, `A`, `192.0.2.1`), rr(`old`, `TXT`, `x`)},
+			desired: []libdns.Record{rr(`www`, `A`, `192.0.2.1`)},
+			diff:    []string{`delete old TXT x 1 -1`},
+		},
+		{
+			name:    `update`,
+			current: []libdns.Record{rr(`www`, `A`, `192.0.2.1`)},
+			desired: []libdns.Record{rr(`www`, `A`, `192.0.2.2`)},
+			diff:    []string{`update www A 192.0.2.2 0 0 old=192.0.2.1`},
+			rrsets:  []string{`update www A 192.0.2.2 0 0 old=192.0.2.1`},
+		},
+		{
+			name:    `shrink RRset`,
+			current: []libdns.Record{rr(`mx`, `MX`, `10 a`), rr(`mx`, `MX`, `20 b`), rr(`mx`, `MX`, `30 c`)},
+			desired: []libdns.Record{rr(`mx`, `MX`, `20 b`), rr(`mx`, `MX`, `40 d`)},
+			diff:    []string{`update mx MX 40 d 0 1 old=10 a`, `delete mx MX 30 c 2 -1`},
+			rrsets:  []string{`update mx MX 40 d 0 1 old=10 a`, `delete mx MX 30 c 2 -1`},
+		},
+		{
+			name:    `grow RRset`,
+			current: []libdns.Record{rr(`ns`, `NS`, `a`)},
+			desired: []libdns.Record{rr(`ns`, `NS`, `b`), rr(`ns`, `NS`, `c`), rr(`ns`, `NS`, `a`)},
+			diff:    []string{`create ns NS b -1 0`, `create ns NS c -1 1`},
+			rrsets:  []string{`create ns NS b -1 0`, `create ns NS c -1 1`},
+		},
+		{
+			name:    `duplicate desired`,
+			current: []libdns.Record{rr(`www`, `A`, `192.0.2.1`)},
+			desired: []libdns.Record{rr(`www`, `A`, `192.0.2.1`), rr(`www`, `A`, `192.0.2.1`)},
+			diff:    []string{`create www A 192.0.2.1 -1 1`},
+			rrsets:  []string{`create www A 192.0.2.1 -1 1`},
+		},
+		{
+			name:    `duplicate current`,
+			current: []libdns.Record{rr(`www`, `A`, `192.0.2.1`), rr(`www`, `A`, `192.0.2.1`)},
+			desired: []libdns.Record{rr(`www`, `A`, `192.0.2.1`)},
+			diff:    []string{`delete www A 192.0.2.1 1 -1`},
+			rrsets:  []string{`delete www A 192.0.2.1 1 -1`},
+		},
+		{
+			name: `ordering`,
+			current: []libdns.Record{
+				rr(`z`, `TXT`, `gone`), rr(`b`, `A`, `192.0.2.1`), rr(`y`, `TXT`, `gone`), rr(`a`, `A`, `192.0.2.1`),
+			},
+			desired: []libdns.Record{rr(`b`, `A`, `192.0.2.2`), rr(`c`, `A`, `192.0.2.3`), rr(`a`, `A`, `192.0.2.4`)},
+			diff: []string{
+				`update b A 192.0.2.2 1 0 old=192.0.2.1`,
+				`create c A 192.0.2.3 -1 1`,
+				`update a A 192.0.2.4 3 2 old=192.0.2.1`,
+				`delete z TXT gone 0 -1`,
+				`delete y TXT gone 2 -1`,
+			},
+			rrsets: []string{
+				`update b A 192.0.2.2 1 0 old=192.0.2.1`,
+				`create c A 192.0.2.3 -1 1`,
+				`update a A 192.0.2.4 3 2 old=192.0.2.1`,
+			},
+		},
+	} {
+		if got := ops(Diff(c.current, c.desired)); !equal(got, c.diff) {
+			t.Errorf("%s: Diff\n%q\nwant\n%q", c.name, got, c.diff)
+		}
+		if got := ops(RRsets(c.current, c.desired)); !equal(got, c.rrsets) {
+			t.Errorf("%s: RRsets\n%q\nwant\n%q", c.name, got, c.rrsets)
+		}
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package libdynv6
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/miekg/dns"
+)
+
+// ToDNS converts rr, with its name relative to zone, to a miekg/dns RR with
+// a fully qualified name. The inverse of the conversion done by
+// [ParseZoneFile].
+func ToDNS(rr libdns.RR, zone string) (dns.RR, error) {
+	origin := dns.Fqdn(zoneName(zone))
+	rr.Name = dns.Fqdn(libdns.AbsoluteName(rr.Name, origin))
+	o, err := dns.NewRR(zoneFileLine(rr))
+	if err != nil {
+		return nil, fmt.Errorf(`%s %s %q: %v`, rr.Name, rr.Type, rr.Data, err)
+	}
+	return o, nil
+}
+
+// DNSRecords returns the records of the snapshot as miekg/dns RRs with fully
+// qualified names, e.g. for serving the zone by AXFR or signing it. There
+// is no SOA record, since dynv6 does not expose it, see [Snapshot.SOA].
+func (s *Snapshot) DNSRecords() ([]dns.RR, error) {
+	o := make([]dns.RR, len(s.Records))
+	for i, r := range s.Records {
+		x, err := ToDNS(r, s.Zone.Name)
+		if err != nil {
+			return nil, err
+		}
+		o[i] = x
+	}
+	return o, nil
+}
+
+// DNSRecords returns the records of zone as miekg/dns RRs, see
+// [Snapshot.DNSRecords]. Unlike in a snapshot, data is expanded as served
+// by dynv6, e.g. AAAA records relative to the IPv6 prefix of the zone hold
+// the full address, see [Record.Expanded].
+func (p *Provider) DNSRecords(ctx context.Context, zone string) ([]dns.RR, error) {
+	p.o.Do(p.init)
+	_, r, err := p.records(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	o := make([]dns.RR, len(r))
+	for i := range r {
+		x := p.libdnsRecord(&r[i])
+		rr := x.RR()
+		if x.Expanded != `` {
+			rr.Data = x.Expanded
+		}
+		if o[i], err = ToDNS(rr, zone); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// SOA returns a SOA record for the snapshot, for callers serving the zone
+// themselves. The serial is derived from Zone.UpdatedAt, or from Time if
+// it is unknown; the other fields are set to the given values or, if zero,
+// to the first of [Nameservers] and common defaults.
+func (s *Snapshot) SOA(ns, mbox string, ttl time.Duration) *dns.SOA {
+	origin := dns.Fqdn(zoneName(s.Zone.Name))
+	if ns == `` {
+		ns = Nameservers[0]
+	}
+	if mbox == `` {
+		mbox = `hostmaster.` + origin
+	}
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	t := s.Zone.UpdatedAt
+	if t.IsZero() {
+		t = s.Time
+	}
+	return &dns.SOA{
+		Hdr:     dns.RR_Header{Name: origin, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: uint32(ttl / time.Second)},
+		Ns:      dns.Fqdn(ns),
+		Mbox:    dns.Fqdn(mbox),
+		Serial:  uint32(t.Unix()),
+		Refresh: 3600,
+		Retry:   600,
+		Expire:  604800,
+		Minttl:  uint32(ttl / time.Second),
+	}
+}
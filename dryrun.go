@@ -0,0 +1,50 @@
+package libdynv6
+
+import (
+	"time"
+
+	"github.com/ZxwyProject/dynv6"
+	"github.com/libdns/libdns"
+)
+
+// dryRun records an operation that was skipped because of DryRun.
+func (p *Provider) dryRun(op, zone, id string, r *libdns.RR) {
+	e := AuditEntry{
+		Time: time.Now().UTC(),
+		Op:   op,
+		Zone: zone,
+		ID:   id,
+		OK:   true,
+	}
+	if r != nil {
+		e.Record = *r
+	}
+	if dynv6.Debug {
+		dynv6.DbgLog.Println(`[Dynv6-debug/libdns] dry-run:`, op, zone, e.Record.Name, e.Record.Type, e.Record.Data)
+	}
+
+	p.am.Lock()
+	p.dry = append(p.dry, e)
+	p.am.Unlock()
+}
+
+// DryRunOps returns the mutations skipped in DryRun mode so far, in order.
+func (p *Provider) DryRunOps() []AuditEntry {
+	p.am.Lock()
+	defer p.am.Unlock()
+	return append([]AuditEntry(nil), p.dry...)
+}
+
+// dryRecord synthesizes the record the API would return for q, without ID.
+func dryRecord(q *dynv6.RecordReq) *dynv6.Record {
+	return &dynv6.Record{
+		Type:     q.Type,
+		Name:     q.Name,
+		Data:     q.Data,
+		Priority: q.Priority,
+		Flags:    q.Flags,
+		Tag:      q.Tag,
+		Weight:   q.Weight,
+		Port:     q.Port,
+	}
+}
@@ -0,0 +1,132 @@
+package dynv6test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// Contract validates the libdns semantics claimed by libdynv6 against p,
+// which may be a [Memory], a provider of a [Server] or a [Live] one:
+//
+//   - AppendRecords adds to existing RRsets and never changes records
+//   - SetRecords replaces only the RRsets given, keeping other types at the same name
+//   - DeleteRecords deletes exact matches only, empty Data matches the whole RRset
+//   - record names are relative to the zone
+//
+// It uses a random name in zone and deletes all its records again.
+func Contract(ctx context.Context, p Provider, zone string) (err error) {
+	name := TestName()
+	a := func(ip string) libdns.Record {
+		return libdns.Address{Name: name, TTL: time.Minute, IP: netip.MustParseAddr(ip)}
+	}
+	txt := libdns.TXT{Name: name, TTL: time.Minute, Text: `libdynv6 contract`}
+	defer func() {
+		_, e := p.DeleteRecords(ctx, zone, []libdns.Record{libdns.RR{Name: name}})
+		if e != nil {
+			err = errors.Join(err, fmt.Errorf(`cleanup: %v`, e))
+		}
+	}()
+
+	steps := []struct {
+		what string
+		do   func() error
+		want []libdns.Record
+	}{{
+		`append RRset`,
+		func() error {
+			_, err := p.AppendRecords(ctx, zone, []libdns.Record{a(`192.0.2.1`), txt})
+			return err
+		},
+		[]libdns.Record{a(`192.0.2.1`), txt},
+	}, {
+		`append to RRset`,
+		func() error {
+			_, err := p.AppendRecords(ctx, zone, []libdns.Record{a(`192.0.2.2`)})
+			return err
+		},
+		[]libdns.Record{a(`192.0.2.1`), a(`192.0.2.2`), txt},
+	}, {
+		`append existing`,
+		func() error {
+			o, err := p.AppendRecords(ctx, zone, []libdns.Record{a(`192.0.2.2`)})
+			if err == nil && len(o) != 0 {
+				err = fmt.Errorf(`returned %d records, want 0`, len(o))
+			}
+			return err
+		},
+		[]libdns.Record{a(`192.0.2.1`), a(`192.0.2.2`), txt},
+	}, {
+		`set RRset`,
+		func() error {
+			_, err := p.SetRecords(ctx, zone, []libdns.Record{a(`192.0.2.3`)})
+			return err
+		},
+		[]libdns.Record{a(`192.0.2.3`), txt},
+	}, {
+		`set multi-value RRset`,
+		func() error {
+			_, err := p.SetRecords(ctx, zone, []libdns.Record{a(`192.0.2.3`), a(`192.0.2.4`)})
+			return err
+		},
+		[]libdns.Record{a(`192.0.2.3`), a(`192.0.2.4`), txt},
+	}, {
+		`delete mismatch`,
+		func() error {
+			o, err := p.DeleteRecords(ctx, zone, []libdns.Record{a(`192.0.2.9`)})
+			if err == nil && len(o) != 0 {
+				err = fmt.Errorf(`returned %d records, want 0`, len(o))
+			}
+			return err
+		},
+		[]libdns.Record{a(`192.0.2.3`), a(`192.0.2.4`), txt},
+	}, {
+		`delete exact`,
+		func() error {
+			o, err := p.DeleteRecords(ctx, zone, []libdns.Record{a(`192.0.2.3`)})
+			if err == nil && len(o) != 1 {
+				err = fmt.Errorf(`returned %d records, want 1`, len(o))
+			}
+			return err
+		},
+		[]libdns.Record{a(`192.0.2.4`), txt},
+	}, {
+		`delete RRset`,
+		func() error {
+			_, err := p.DeleteRecords(ctx, zone, []libdns.Record{libdns.RR{Name: name, Type: `TXT`}})
+			return err
+		},
+		[]libdns.Record{a(`192.0.2.4`)},
+	}}
+
+	for _, s := range steps {
+		if err = s.do(); err != nil {
+			return fmt.Errorf(`%s: %v`, s.what, err)
+		}
+		if err = expectRecords(ctx, p, zone, name, s.want); err != nil {
+			return fmt.Errorf(`%s: %v`, s.what, err)
+		}
+	}
+	return relativeNames(ctx, p, zone)
+}
+
+// relativeNames checks that GetRecords returns names relative to zone.
+func relativeNames(ctx context.Context, p libdns.RecordGetter, zone string) error {
+	r, err := p.GetRecords(ctx, zone)
+	if err != nil {
+		return err
+	}
+	zone = strings.TrimSuffix(zone, `.`)
+	for _, x := range r {
+		n := x.RR().Name
+		if strings.HasSuffix(n, `.`) || n == zone || strings.HasSuffix(n, `.`+zone) {
+			return fmt.Errorf(`record name %q is not relative to %s`, n, zone)
+		}
+	}
+	return nil
+}
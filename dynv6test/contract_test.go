@@ -0,0 +1,30 @@
+package dynv6test
+
+import (
+	"context"
+	"testing"
+)
+
+const testZone = `example.dynv6.net`
+
+func TestContractMemory(t *testing.T) {
+	m := NewMemory(testZone)
+	if err := Contract(context.Background(), m, testZone); err != nil {
+		t.Fatal(err)
+	}
+	if r := m.Records(testZone); len(r) != 0 {
+		t.Fatalf(`records left: %v`, r)
+	}
+}
+
+func TestContractServer(t *testing.T) {
+	s := NewServer(`token`)
+	defer s.Close()
+	s.AddZone(testZone)
+	if err := Contract(context.Background(), s.Provider(), testZone); err != nil {
+		t.Fatal(err)
+	}
+	if r := s.Records(testZone); len(r) != 0 {
+		t.Fatalf(`records left: %v`, r)
+	}
+}
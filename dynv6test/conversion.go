@@ -0,0 +1,95 @@
+package dynv6test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/libdns/libdns"
+)
+
+// ConversionCase is a record as stored by dynv6 together with its expected
+// libdns representation.
+type ConversionCase struct {
+	Raw Record    `json:"raw"` // ID, ZoneID and ExpandedData are ignored
+	RR  libdns.RR `json:"rr"`  // TTL is ignored
+}
+
+// ConversionCases cover every record type supported by libdynv6.
+var ConversionCases = []ConversionCase{
+	{Record{Type: `A`, Name: `a`, Data: `192.0.2.1`}, libdns.RR{Name: `a`, Type: `A`, Data: `192.0.2.1`}},
+	{Record{Type: `AAAA`, Name: `a`, Data: `2001:db8::1`}, libdns.RR{Name: `a`, Type: `AAAA`, Data: `2001:db8::1`}},
+	{Record{Type: `CNAME`, Name: `www`, Data: `example.com.`}, libdns.RR{Name: `www`, Type: `CNAME`, Data: `example.com.`}},
+	{Record{Type: `NS`, Name: `sub`, Data: `ns1.example.com.`}, libdns.RR{Name: `sub`, Type: `NS`, Data: `ns1.example.com.`}},
+	{Record{Type: `TXT`, Name: `txt`, Data: `v=spf1 -all`}, libdns.RR{Name: `txt`, Type: `TXT`, Data: `v=spf1 -all`}},
+	{Record{Type: `SPF`, Name: `spf`, Data: `v=spf1 -all`}, libdns.RR{Name: `spf`, Type: `SPF`, Data: `v=spf1 -all`}},
+	{Record{Type: `MX`, Name: `mx`, Data: `mail.example.com.`, Priority: 10}, libdns.RR{Name: `mx`, Type: `MX`, Data: `10 mail.example.com.`}},
+	{Record{Type: `MX`, Name: `mx0`, Data: `mail.example.com.`}, libdns.RR{Name: `mx0`, Type: `MX`, Data: `0 mail.example.com.`}},
+	{Record{Type: `MX`, Name: `nullmx`, Data: `.`}, libdns.RR{Name: `nullmx`, Type: `MX`, Data: `0 .`}},
+	{Record{Type: `SRV`, Name: `_sip._tcp`, Data: `sip.example.com.`, Priority: 1, Weight: 2, Port: 5060}, libdns.RR{Name: `_sip._tcp`, Type: `SRV`, Data: `1 2 5060 sip.example.com.`}},
+	{Record{Type: `SRV`, Name: `_sip._udp`, Data: `sip.example.com.`}, libdns.RR{Name: `_sip._udp`, Type: `SRV`, Data: `0 0 0 sip.example.com.`}},
+	{Record{Type: `CAA`, Name: `caa`, Data: `letsencrypt.org`, Tag: `issue`}, libdns.RR{Name: `caa`, Type: `CAA`, Data: `0 issue "letsencrypt.org"`}},
+	{Record{Type: `CAA`, Name: `caa2`, Data: `mailto:ca@example.com`, Tag: `iodef`, Flags: 128}, libdns.RR{Name: `caa2`, Type: `CAA`, Data: `128 iodef "mailto:ca@example.com"`}},
+	{Record{Type: `CAA`, Name: `caa3`, Data: `ca.example; account="a b"`, Tag: `issue`}, libdns.RR{Name: `caa3`, Type: `CAA`, Data: `0 issue "ca.example; account=\"a b\""`}},
+}
+
+// LoadConversionCases reads cases from a JSON golden file.
+func LoadConversionCases(file string) ([]ConversionCase, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var o []ConversionCase
+	if err = json.Unmarshal(b, &o); err != nil {
+		return nil, fmt.Errorf(`%s: %v`, file, err)
+	}
+	return o, nil
+}
+
+// CheckConversion verifies that every case survives dynv6 → libdns → dynv6
+// conversion through the provider unchanged: reading Raw yields RR, and
+// writing that back stores a record equal to Raw.
+func CheckConversion(ctx context.Context, cases []ConversionCase) error {
+	var errs []error
+	for i := range cases {
+		if err := checkConversion(ctx, &cases[i]); err != nil {
+			errs = append(errs, fmt.Errorf(`%s %s: %v`, cases[i].Raw.Name, cases[i].Raw.Type, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func checkConversion(ctx context.Context, c *ConversionCase) error {
+	const src, dst = `src.test`, `dst.test`
+	m := NewMemory(src, dst)
+	m.AddRecord(src, c.Raw)
+
+	r, err := m.GetRecords(ctx, src)
+	if err != nil {
+		return err
+	}
+	if len(r) != 1 {
+		return fmt.Errorf(`read %d records, want 1`, len(r))
+	}
+	rr := r[0].RR()
+	if rr.Name != c.RR.Name || rr.Type != c.RR.Type || rr.Data != c.RR.Data {
+		return fmt.Errorf(`read %q %q %q, want %q %q %q`, rr.Name, rr.Type, rr.Data, c.RR.Name, c.RR.Type, c.RR.Data)
+	}
+
+	if _, err = m.SetRecords(ctx, dst, r); err != nil {
+		return err
+	}
+	l := m.Records(dst)
+	if len(l) != 1 {
+		return fmt.Errorf(`wrote %d records, want 1`, len(l))
+	}
+	have, want := l[0], c.Raw
+	have.ID, have.ZoneID, have.ExpandedData = 0, 0, ``
+	want.ID, want.ZoneID, want.ExpandedData = 0, 0, ``
+	if have != want {
+		return fmt.Errorf(`wrote %+v, want %+v`, have, want)
+	}
+	return nil
+}
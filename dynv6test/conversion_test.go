@@ -0,0 +1,12 @@
+package dynv6test
+
+import (
+	"context"
+	"testing"
+)
+
+func TestConversionCases(t *testing.T) {
+	if err := CheckConversion(context.Background(), ConversionCases); err != nil {
+		t.Fatal(err)
+	}
+}
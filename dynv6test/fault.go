@@ -0,0 +1,80 @@
+package dynv6test
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/ZxwyProject/libdynv6"
+)
+
+// Fault fails the N-th call (1-based) of an API operation with Status.
+type Fault struct {
+	Op     string // libdynv6.OpCreate, libdynv6.OpUpdate, ...
+	N      int
+	Status int // defaults to 500 Internal Server Error
+}
+
+// Inject returns a [Server.Fail] hook applying faults deterministically,
+// e.g. to fail the third record creation:
+//
+//	s.Fail = dynv6test.Inject(dynv6test.Fault{Op: libdynv6.OpCreate, N: 3})
+//
+// Calls are counted per operation, including failed ones.
+func Inject(faults ...Fault) func(r *http.Request) (status int) {
+	var mu sync.Mutex
+	n := make(map[string]int)
+
+	return func(r *http.Request) int {
+		op := Op(r)
+		mu.Lock()
+		n[op]++
+		i := n[op]
+		mu.Unlock()
+
+		for _, f := range faults {
+			if f.Op == op && f.N == i {
+				if f.Status == 0 {
+					return http.StatusInternalServerError
+				}
+				return f.Status
+			}
+		}
+		return 0
+	}
+}
+
+// Op returns the libdynv6 operation (see [libdynv6.Provider.Stats]) of an
+// API request, or "" if unknown.
+func Op(r *http.Request) string {
+	p := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, APIPath), `/`), `/`)
+	if len(p) == 0 || p[0] != `zones` {
+		return ``
+	}
+	switch {
+	case len(p) == 1:
+		return libdynv6.OpZones
+	case len(p) == 3 && p[2] == `records`:
+		if r.Method == http.MethodPost {
+			return libdynv6.OpCreate
+		}
+		return libdynv6.OpRecords
+	case len(p) == 4 && p[2] == `records`:
+		switch r.Method {
+		case http.MethodPatch:
+			return libdynv6.OpUpdate
+		case http.MethodDelete:
+			return libdynv6.OpDelete
+		}
+	case len(p) <= 3:
+		switch r.Method {
+		case http.MethodGet:
+			return libdynv6.OpZone
+		case http.MethodPatch:
+			return libdynv6.OpZoneUpdate
+		case http.MethodDelete:
+			return libdynv6.OpZoneDelete
+		}
+	}
+	return ``
+}
@@ -0,0 +1,201 @@
+package dynv6test
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ZxwyProject/libdynv6"
+	"github.com/libdns/libdns"
+)
+
+// Environment variables enabling live tests against the real dynv6 API.
+const (
+	EnvToken = `DYNV6_TOKEN`     // HTTP token
+	EnvZone  = `DYNV6_TEST_ZONE` // throwaway zone, its records may be changed
+)
+
+// Provider is the set of libdns interfaces exercised by [RoundTrip].
+type Provider interface {
+	libdns.RecordGetter
+	libdns.RecordAppender
+	libdns.RecordSetter
+	libdns.RecordDeleter
+}
+
+// Live returns a provider for the throwaway zone named by EnvZone, or
+// ok false if EnvToken or EnvZone is not set, so callers can skip:
+//
+//	p, zone, cleanup, ok := dynv6test.Live()
+//	if !ok {
+//		t.Skip(`DYNV6_TOKEN and DYNV6_TEST_ZONE not set`)
+//	}
+//	defer cleanup(context.Background())
+//
+// Every RRset created or updated through p is remembered, and cleanup
+// deletes all records of those RRsets from the zone.
+func Live() (p *libdynv6.Provider, zone string, cleanup func(context.Context) error, ok bool) {
+	token, zone := os.Getenv(EnvToken), os.Getenv(EnvZone)
+	if token == `` || zone == `` {
+		return nil, ``, nil, false
+	}
+	p = &libdynv6.Provider{Token: token}
+	t := &tracker{}
+	p.AuditFunc = t.audit
+	return p, zone, func(ctx context.Context) error { return t.cleanup(ctx, p) }, true
+}
+
+type rrset struct{ zone, name, typ string }
+
+// tracker remembers the RRsets touched through a provider.
+type tracker struct {
+	mu   sync.Mutex
+	sets []rrset
+}
+
+func (t *tracker) audit(e libdynv6.AuditEntry) {
+	if e.Op != libdynv6.AuditCreate && e.Op != libdynv6.AuditUpdate {
+		return
+	}
+	k := rrset{e.Zone, e.Record.Name, e.Record.Type}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, s := range t.sets {
+		if s == k {
+			return
+		}
+	}
+	t.sets = append(t.sets, k)
+}
+
+func (t *tracker) cleanup(ctx context.Context, p Provider) error {
+	t.mu.Lock()
+	sets := t.sets
+	t.sets = nil
+	t.mu.Unlock()
+
+	var errs []error
+	byZone := make(map[string][]rrset)
+	for _, s := range sets {
+		byZone[s.zone] = append(byZone[s.zone], s)
+	}
+	for zone, sets := range byZone {
+		r, err := p.GetRecords(ctx, zone)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		var del []libdns.Record
+		for _, x := range r {
+			rr := x.RR()
+			for _, s := range sets {
+				if rr.Name == s.name && rr.Type == s.typ {
+					del = append(del, x)
+					break
+				}
+			}
+		}
+		if len(del) > 0 {
+			if _, err = p.DeleteRecords(ctx, zone, del); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// TestName returns a random record name unlikely to collide with existing
+// records, for records created by tests.
+func TestName() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return `libdynv6-test-` + hex.EncodeToString(b)
+}
+
+// RoundTrip exercises create, read, update and delete of A, TXT and MX
+// records in zone through p. All records it creates are deleted again,
+// also on failure.
+func RoundTrip(ctx context.Context, p Provider, zone string) (err error) {
+	name := TestName()
+	created := []libdns.Record{
+		libdns.Address{Name: name, TTL: time.Minute, IP: netip.MustParseAddr(`192.0.2.1`)},
+		libdns.TXT{Name: name, TTL: time.Minute, Text: `libdynv6 round trip`},
+		libdns.MX{Name: name, TTL: time.Minute, Preference: 10, Target: `mail.example.com.`},
+	}
+	defer func() {
+		r, e := p.GetRecords(ctx, zone)
+		if e == nil {
+			var del []libdns.Record
+			for _, x := range r {
+				if x.RR().Name == name {
+					del = append(del, x)
+				}
+			}
+			_, e = p.DeleteRecords(ctx, zone, del)
+		}
+		if e != nil {
+			err = errors.Join(err, fmt.Errorf(`cleanup: %v`, e))
+		}
+	}()
+
+	if _, err = p.AppendRecords(ctx, zone, created); err != nil {
+		return fmt.Errorf(`append: %v`, err)
+	}
+	if err = expectRecords(ctx, p, zone, name, created); err != nil {
+		return fmt.Errorf(`after append: %v`, err)
+	}
+
+	updated := []libdns.Record{
+		libdns.Address{Name: name, TTL: time.Minute, IP: netip.MustParseAddr(`192.0.2.2`)},
+		created[1], created[2],
+	}
+	if _, err = p.SetRecords(ctx, zone, updated[:1]); err != nil {
+		return fmt.Errorf(`set: %v`, err)
+	}
+	if err = expectRecords(ctx, p, zone, name, updated); err != nil {
+		return fmt.Errorf(`after set: %v`, err)
+	}
+
+	if _, err = p.DeleteRecords(ctx, zone, updated); err != nil {
+		return fmt.Errorf(`delete: %v`, err)
+	}
+	if err = expectRecords(ctx, p, zone, name, nil); err != nil {
+		return fmt.Errorf(`after delete: %v`, err)
+	}
+	return nil
+}
+
+// expectRecords checks that the records named name in zone are exactly want,
+// compared by name, type and data.
+func expectRecords(ctx context.Context, p libdns.RecordGetter, zone, name string, want []libdns.Record) error {
+	r, err := p.GetRecords(ctx, zone)
+	if err != nil {
+		return err
+	}
+	have := make(map[string]int)
+	for _, x := range r {
+		if rr := x.RR(); rr.Name == name {
+			have[rr.Type+` `+rr.Data]++
+		}
+	}
+	for _, x := range want {
+		rr := x.RR()
+		k := rr.Type + ` ` + rr.Data
+		if have[k] == 0 {
+			return fmt.Errorf(`missing %s %s`, name, k)
+		}
+		have[k]--
+	}
+	for k, n := range have {
+		if n > 0 {
+			return fmt.Errorf(`unexpected %s %s`, name, k)
+		}
+	}
+	return nil
+}
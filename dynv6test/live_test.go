@@ -0,0 +1,26 @@
+package dynv6test
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLive(t *testing.T) {
+	p, zone, cleanup, ok := Live()
+	if !ok {
+		t.Skip(`DYNV6_TOKEN and DYNV6_TEST_ZONE not set`)
+	}
+	ctx := context.Background()
+	defer func() {
+		if err := cleanup(ctx); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	if err := RoundTrip(ctx, p, zone); err != nil {
+		t.Error(err)
+	}
+	if err := Contract(ctx, p, zone); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,74 @@
+package dynv6test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/ZxwyProject/libdynv6"
+	"github.com/libdns/libdns"
+)
+
+// memoryURL is the base URL of the API served in memory. It is never resolved.
+const memoryURL = `http://dynv6.invalid` + APIPath
+
+// Memory is a provider backed by an in-memory fake API instead of dynv6.
+// Requests never leave the process, and since the records go through the
+// real [libdynv6.Provider], matching semantics are identical to production.
+// Swap it for a configured [libdynv6.Provider] outside of tests.
+type Memory struct {
+	*libdynv6.Provider
+
+	api *Server
+}
+
+// NewMemory returns an empty in-memory provider with zones.
+func NewMemory(zones ...string) *Memory {
+	s := newServer(`memory`)
+	for _, z := range zones {
+		s.AddZone(z)
+	}
+	return &Memory{
+		Provider: &libdynv6.Provider{
+			Token:      s.Token,
+			BaseURL:    memoryURL,
+			HTTPClient: &http.Client{Transport: handlerTransport{http.HandlerFunc(s.serve)}},
+		},
+		api: s,
+	}
+}
+
+// AddZone creates a zone, see [Server.AddZone].
+func (m *Memory) AddZone(name string) Zone { return m.api.AddZone(name) }
+
+// AddRecord creates a record bypassing the provider, see [Server.AddRecord].
+func (m *Memory) AddRecord(zone string, r Record) (Record, bool) { return m.api.AddRecord(zone, r) }
+
+// Records returns the raw records of zone, see [Server.Records].
+func (m *Memory) Records(zone string) []Record { return m.api.Records(zone) }
+
+// SetFail sets the error injection hook, see [Server.Fail].
+// It must not be called concurrently with requests.
+func (m *Memory) SetFail(f func(r *http.Request) (status int)) { m.api.Fail = f }
+
+// handlerTransport serves requests by h in-process.
+type handlerTransport struct {
+	h http.Handler
+}
+
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		defer req.Body.Close()
+	}
+	w := httptest.NewRecorder()
+	t.h.ServeHTTP(w, req)
+	return w.Result(), nil
+}
+
+// Interface guards
+var (
+	_ libdns.RecordGetter   = (*Memory)(nil)
+	_ libdns.RecordAppender = (*Memory)(nil)
+	_ libdns.RecordSetter   = (*Memory)(nil)
+	_ libdns.RecordDeleter  = (*Memory)(nil)
+	_ libdns.ZoneLister     = (*Memory)(nil)
+)
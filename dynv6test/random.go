@@ -0,0 +1,103 @@
+package dynv6test
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/ZxwyProject/libdynv6"
+	"github.com/libdns/libdns"
+)
+
+// RandomConversionCases returns n random valid records of every record type
+// supported by libdynv6 together with their expected libdns representation,
+// for property-style checks with [CheckConversion]:
+//
+//	cases := dynv6test.RandomConversionCases(rand.New(rand.NewSource(seed)), 100)
+//	err := dynv6test.CheckConversion(ctx, cases)
+//
+// Names are unique within the result, so all cases fit in one zone.
+func RandomConversionCases(r *rand.Rand, n int) []ConversionCase {
+	types := []string{`A`, `AAAA`, `CNAME`, `NS`, `TXT`, `SPF`, `MX`, `SRV`, `CAA`}
+	o := make([]ConversionCase, 0, n*len(types))
+	for i := 0; i < n; i++ {
+		for _, t := range types {
+			name := fmt.Sprintf(`%s-%d`, strings.ToLower(t), i)
+			o = append(o, randomCase(r, t, name))
+		}
+	}
+	return o
+}
+
+func randomCase(r *rand.Rand, t, name string) ConversionCase {
+	raw := Record{Type: t, Name: name}
+	var data string
+	switch t {
+	case `A`:
+		ip := make(net.IP, net.IPv4len)
+		r.Read(ip)
+		raw.Data = ip.String()
+		data = raw.Data
+	case `AAAA`:
+		ip := make(net.IP, net.IPv6len)
+		r.Read(ip)
+		ip[0] = 0x20 // not IPv4-mapped
+		raw.Data = ip.String()
+		data = raw.Data
+	case `CNAME`, `NS`:
+		raw.Data = randomHost(r)
+		data = raw.Data
+	case `TXT`, `SPF`:
+		raw.Data = randomText(r, false)
+		data = raw.Data
+	case `MX`:
+		raw.Priority = uint16(r.Intn(1 << 16))
+		raw.Data = randomHost(r)
+		data = fmt.Sprintf(`%d %s`, raw.Priority, raw.Data)
+	case `SRV`:
+		raw.Name = `_` + name + `._tcp`
+		raw.Priority = uint16(r.Intn(1 << 16))
+		raw.Weight = uint16(r.Intn(1 << 16))
+		raw.Port = uint16(r.Intn(1 << 16))
+		raw.Data = randomHost(r)
+		data = fmt.Sprintf(`%d %d %d %s`, raw.Priority, raw.Weight, raw.Port, raw.Data)
+	case `CAA`:
+		raw.Flags = uint8(r.Intn(2)) * libdynv6.CAAFlagCritical
+		raw.Tag = []string{`issue`, `issuewild`, `iodef`}[r.Intn(3)]
+		raw.Data = randomText(r, true)
+		data = fmt.Sprintf(`%d %s %s`, raw.Flags, raw.Tag, strconv.Quote(raw.Data))
+	}
+	return ConversionCase{raw, libdns.RR{Name: raw.Name, Type: t, Data: data}}
+}
+
+// randomHost returns a random absolute lowercase host name.
+func randomHost(r *rand.Rand) string {
+	const chars = `abcdefghijklmnopqrstuvwxyz0123456789`
+	l := make([]string, 2+r.Intn(3))
+	for i := range l {
+		b := make([]byte, 1+r.Intn(12))
+		for j := range b {
+			b[j] = chars[r.Intn(len(chars))]
+		}
+		l[i] = string(b)
+	}
+	return strings.Join(l, `.`) + `.`
+}
+
+// randomText returns random printable text, including spaces and quotes,
+// and non-ASCII characters if unicode is set.
+func randomText(r *rand.Rand, unicode bool) string {
+	const chars = ` !"#$%&'()*+,-./0123456789:;<=>?@ABCXYZ[\]^_abcxyz{|}~`
+	extra := []rune(`äöüß€日本`)
+	b := make([]rune, 1+r.Intn(40))
+	for i := range b {
+		if unicode && r.Intn(8) == 0 {
+			b[i] = extra[r.Intn(len(extra))]
+		} else {
+			b[i] = rune(chars[r.Intn(len(chars))])
+		}
+	}
+	return strings.TrimSpace(string(b)) + `x`
+}
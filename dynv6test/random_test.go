@@ -0,0 +1,14 @@
+package dynv6test
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+)
+
+func TestRandomConversionCases(t *testing.T) {
+	cases := RandomConversionCases(rand.New(rand.NewSource(659)), 50)
+	if err := CheckConversion(context.Background(), cases); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,219 @@
+package dynv6test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Interaction is one recorded HTTP exchange.
+type Interaction struct {
+	Method       string      `json:"method"`
+	URL          string      `json:"url"`
+	RequestBody  string      `json:"request_body,omitempty"`
+	Status       int         `json:"status"`
+	Header       http.Header `json:"header,omitempty"`
+	ResponseBody string      `json:"response_body,omitempty"`
+}
+
+// Cassette records real API interactions to a golden file and replays them,
+// so conversion and batching logic can be tested against authentic dynv6
+// responses in CI without credentials.
+//
+// Set it as the Transport of [libdynv6.Provider.HTTPClient]. Credentials
+// (Authorization and cookie headers, token query parameters) are never
+// written to the file.
+type Cassette struct {
+	// File holds the interactions as JSON.
+	File string
+	// Record sends requests to Next and appends them to File on Save.
+	// Otherwise requests are answered from File, in order.
+	Record bool
+	// Transport for recording, defaults to http.DefaultTransport.
+	Next http.RoundTripper
+
+	mu sync.Mutex
+	l  []Interaction
+	i  int
+	ok bool // l loaded
+}
+
+// NewCassette returns a cassette for file, recording if the environment
+// variable DYNV6_RECORD is set and replaying otherwise.
+func NewCassette(file string) *Cassette {
+	return &Cassette{File: file, Record: os.Getenv(`DYNV6_RECORD`) != ``}
+}
+
+// Client returns an HTTP client using c.
+func (c *Cassette) Client() *http.Client {
+	return &http.Client{Transport: c}
+}
+
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if c.Record {
+		return c.record(req, body)
+	}
+	return c.replay(req, body)
+}
+
+func (c *Cassette) record(req *http.Request, body []byte) (*http.Response, error) {
+	next := c.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	r := req.Clone(req.Context())
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	resp, err := next.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(b))
+
+	c.mu.Lock()
+	c.l = append(c.l, Interaction{
+		Method:       req.Method,
+		URL:          sanitizeURL(req.URL),
+		RequestBody:  string(body),
+		Status:       resp.StatusCode,
+		Header:       sanitizeHeader(resp.Header),
+		ResponseBody: string(b),
+	})
+	c.mu.Unlock()
+	return resp, nil
+}
+
+func (c *Cassette) replay(req *http.Request, body []byte) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.ok {
+		if err := c.load(); err != nil {
+			return nil, err
+		}
+	}
+	u := sanitizeURL(req.URL)
+	if c.i >= len(c.l) {
+		return nil, fmt.Errorf(`dynv6test: unexpected request %s %s, cassette %s exhausted`, req.Method, u, c.File)
+	}
+	in := &c.l[c.i]
+	if in.Method != req.Method || in.URL != u || !jsonEqual(in.RequestBody, string(body)) {
+		return nil, fmt.Errorf(`dynv6test: request %d is %s %s, cassette %s expects %s %s`,
+			c.i, req.Method, u, c.File, in.Method, in.URL)
+	}
+	c.i++
+
+	h := in.Header.Clone()
+	if h == nil {
+		h = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf(`%d %s`, in.Status, http.StatusText(in.Status)),
+		StatusCode:    in.Status,
+		Proto:         `HTTP/1.1`,
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          io.NopCloser(strings.NewReader(in.ResponseBody)),
+		ContentLength: int64(len(in.ResponseBody)),
+		Request:       req,
+	}, nil
+}
+
+func (c *Cassette) load() error {
+	b, err := os.ReadFile(c.File)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(b, &c.l); err != nil {
+		return fmt.Errorf(`dynv6test: %s: %v`, c.File, err)
+	}
+	c.ok = true
+	return nil
+}
+
+// Save writes the recorded interactions to File. It does nothing when replaying.
+func (c *Cassette) Save() error {
+	if !c.Record {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, err := json.MarshalIndent(c.l, ``, `  `)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.File, append(b, '\n'), 0o644)
+}
+
+// Done reports an error if not all recorded interactions were replayed.
+func (c *Cassette) Done() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Record || c.i == len(c.l) {
+		return nil
+	}
+	return fmt.Errorf(`dynv6test: %d of %d interactions in %s not replayed`, len(c.l)-c.i, len(c.l), c.File)
+}
+
+// sanitizeURL returns u without credentials.
+func sanitizeURL(u *url.URL) string {
+	v := *u
+	v.User = nil
+	q := v.Query()
+	for k := range q {
+		switch strings.ToLower(k) {
+		case `token`, `password`:
+			q.Set(k, redacted)
+		}
+	}
+	v.RawQuery = q.Encode()
+	return v.String()
+}
+
+const redacted = `REDACTED`
+
+// sanitizeHeader returns the response headers worth keeping, without cookies.
+func sanitizeHeader(h http.Header) http.Header {
+	o := http.Header{}
+	for k, v := range h {
+		switch http.CanonicalHeaderKey(k) {
+		case `Set-Cookie`, `Authorization`, `Date`:
+			continue
+		}
+		o[k] = v
+	}
+	return o
+}
+
+// jsonEqual compares request bodies, ignoring JSON formatting.
+func jsonEqual(a, b string) bool {
+	if a == b {
+		return true
+	}
+	var x, y any
+	if json.Unmarshal([]byte(a), &x) != nil || json.Unmarshal([]byte(b), &y) != nil {
+		return false
+	}
+	p, _ := json.Marshal(x)
+	q, _ := json.Marshal(y)
+	return bytes.Equal(p, q)
+}
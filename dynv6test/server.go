@@ -0,0 +1,394 @@
+// Package dynv6test provides an in-process fake of the dynv6 REST API for
+// testing code built on libdynv6 without real credentials.
+//
+//	s := dynv6test.NewServer(`token`)
+//	defer s.Close()
+//	s.AddZone(`example.dynv6.net`)
+//	p := s.Provider()
+package dynv6test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ZxwyProject/libdynv6"
+)
+
+// APIPath is the path under which the API is served, like on dynv6.com.
+const APIPath = `/api/v2`
+
+// Zone is a zone as returned by the API.
+type Zone struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	IPv4Address string    `json:"ipv4address"`
+	IPv6Prefix  string    `json:"ipv6prefix"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// Record is a record as returned by the API.
+type Record struct {
+	ID           int64  `json:"id"`
+	ZoneID       int64  `json:"zoneID"`
+	Type         string `json:"type"`
+	Name         string `json:"name"`
+	Data         string `json:"data"`
+	ExpandedData string `json:"expandedData"`
+	Priority     uint16 `json:"priority,omitempty"`
+	Flags        uint8  `json:"flags,omitempty"`
+	Tag          string `json:"tag,omitempty"`
+	Weight       uint16 `json:"weight,omitempty"`
+	Port         uint16 `json:"port,omitempty"`
+}
+
+// Server is a fake dynv6 REST API backed by memory. It is safe for
+// concurrent use.
+type Server struct {
+	*httptest.Server
+
+	// Token expected in the Authorization header.
+	Token string
+
+	// Fail, if set, is called for every authorized request before it is
+	// handled. A non-zero status is returned to the client instead.
+	Fail func(r *http.Request) (status int)
+
+	mu      sync.Mutex
+	zones   []*Zone
+	records map[int64][]*Record // by zone ID
+	next    int64
+
+	limit, remaining int
+	window           time.Duration
+	reset            time.Time
+	calls            int
+}
+
+// NewServer starts a fake API accepting token.
+func NewServer(token string) *Server {
+	s := newServer(token)
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func newServer(token string) *Server {
+	return &Server{
+		Token:   token,
+		records: make(map[int64][]*Record),
+	}
+}
+
+// Provider returns a provider talking to s.
+func (s *Server) Provider() *libdynv6.Provider {
+	return &libdynv6.Provider{
+		Token:   s.Token,
+		BaseURL: s.URL + APIPath,
+	}
+}
+
+// SetRateLimit allows limit requests per window and answers further
+// requests with 429 Too Many Requests until the window resets. All
+// responses carry X-RateLimit headers. A limit <= 0 disables it.
+func (s *Server) SetRateLimit(limit int, window time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limit, s.remaining, s.window = limit, limit, window
+	s.reset = time.Now().Add(window)
+}
+
+// Calls returns the number of requests served so far.
+func (s *Server) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+// AddZone creates a zone and returns it. It returns the existing zone if
+// name already exists.
+func (s *Server) AddZone(name string) Zone {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name = strings.ToLower(strings.TrimSuffix(name, `.`))
+	if z := s.zoneByName(name); z != nil {
+		return *z
+	}
+	s.next++
+	t := time.Now().UTC()
+	z := &Zone{ID: s.next, Name: name, CreatedAt: t, UpdatedAt: t}
+	s.zones = append(s.zones, z)
+	return *z
+}
+
+// AddRecord creates r in zone, which must exist. ID and ZoneID are set by s.
+func (s *Server) AddRecord(zone string, r Record) (Record, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	z := s.zoneByName(zone)
+	if z == nil {
+		return Record{}, false
+	}
+	return *s.add(z, &r), true
+}
+
+// Records returns a copy of the records of zone, ordered by ID.
+func (s *Server) Records(zone string) []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	z := s.zoneByName(zone)
+	if z == nil {
+		return nil
+	}
+	o := make([]Record, len(s.records[z.ID]))
+	for i, r := range s.records[z.ID] {
+		o[i] = *r
+	}
+	return o
+}
+
+func (s *Server) zoneByName(name string) *Zone {
+	name = strings.ToLower(strings.TrimSuffix(name, `.`))
+	for _, z := range s.zones {
+		if z.Name == name {
+			return z
+		}
+	}
+	return nil
+}
+
+func (s *Server) zoneByID(id string) *Zone {
+	n, _ := strconv.ParseInt(id, 10, 64)
+	for _, z := range s.zones {
+		if z.ID == n {
+			return z
+		}
+	}
+	return nil
+}
+
+func (s *Server) add(z *Zone, r *Record) *Record {
+	s.next++
+	r.ID, r.ZoneID = s.next, z.ID
+	r.ExpandedData = expand(r, z)
+	s.records[z.ID] = append(s.records[z.ID], r)
+	z.UpdatedAt = time.Now().UTC()
+	return r
+}
+
+// valid reports whether r may be stored. Only A and AAAA records may have
+// empty data, which stands for the address of the zone.
+func valid(r *Record) bool {
+	return r.Type != `` && (r.Data != `` || r.Type == `A` || r.Type == `AAAA`)
+}
+
+// expand qualifies relative host names in the data of r like dynv6 does,
+// and fills in the address of z for A and AAAA records with empty data.
+func expand(r *Record, z *Zone) string {
+	switch r.Type {
+	case `CNAME`, `MX`, `NS`, `SRV`:
+		if r.Data != `` && !strings.HasSuffix(r.Data, `.`) {
+			return r.Data + `.` + z.Name + `.`
+		}
+	case `A`:
+		if r.Data == `` {
+			return z.IPv4Address
+		}
+	case `AAAA`:
+		if r.Data == `` {
+			a, _, _ := strings.Cut(z.IPv6Prefix, `/`)
+			return a
+		}
+	}
+	return r.Data
+}
+
+// rateLimit updates the budget and reports whether the request may proceed.
+// s.mu must be held.
+func (s *Server) rateLimit(h http.Header) bool {
+	if s.limit <= 0 {
+		return true
+	}
+	now := time.Now()
+	if !now.Before(s.reset) {
+		s.remaining, s.reset = s.limit, now.Add(s.window)
+	}
+	ok := s.remaining > 0
+	if ok {
+		s.remaining--
+	}
+	h.Set(`X-RateLimit-Limit`, strconv.Itoa(s.limit))
+	h.Set(`X-RateLimit-Remaining`, strconv.Itoa(s.remaining))
+	h.Set(`X-RateLimit-Reset`, strconv.FormatInt(s.reset.Unix(), 10))
+	if !ok {
+		h.Set(`Retry-After`, strconv.Itoa(int(time.Until(s.reset)/time.Second)+1))
+	}
+	return ok
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get(`Authorization`) != `Bearer `+s.Token {
+		fail(w, http.StatusUnauthorized)
+		return
+	}
+	if s.Fail != nil {
+		if c := s.Fail(r); c != 0 {
+			fail(w, c)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if !s.rateLimit(w.Header()) {
+		fail(w, http.StatusTooManyRequests)
+		return
+	}
+
+	p := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, APIPath), `/`), `/`)
+	switch {
+	case len(p) == 1 && p[0] == `zones`:
+		s.serveZones(w, r)
+	case len(p) == 3 && p[0] == `zones` && p[1] == `by-name`:
+		s.serveZone(w, r, s.zoneByName(p[2]))
+	case len(p) == 2 && p[0] == `zones`:
+		s.serveZone(w, r, s.zoneByID(p[1]))
+	case len(p) == 3 && p[0] == `zones` && p[2] == `records`:
+		s.serveRecords(w, r, s.zoneByID(p[1]))
+	case len(p) == 4 && p[0] == `zones` && p[2] == `records`:
+		s.serveRecord(w, r, s.zoneByID(p[1]), p[3])
+	default:
+		fail(w, http.StatusNotFound)
+	}
+}
+
+func (s *Server) serveZones(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		fail(w, http.StatusMethodNotAllowed)
+		return
+	}
+	o := make([]Zone, len(s.zones))
+	for i, z := range s.zones {
+		o[i] = *z
+	}
+	sort.Slice(o, func(i, j int) bool { return o[i].Name < o[j].Name })
+	reply(w, http.StatusOK, o)
+}
+
+func (s *Server) serveZone(w http.ResponseWriter, r *http.Request, z *Zone) {
+	if z == nil {
+		fail(w, http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		reply(w, http.StatusOK, z)
+	case http.MethodPatch:
+		var q struct {
+			IPv4Address *string `json:"ipv4address"`
+			IPv6Prefix  *string `json:"ipv6prefix"`
+		}
+		if json.NewDecoder(r.Body).Decode(&q) != nil {
+			fail(w, http.StatusBadRequest)
+			return
+		}
+		if q.IPv4Address != nil {
+			z.IPv4Address = *q.IPv4Address
+		}
+		if q.IPv6Prefix != nil {
+			z.IPv6Prefix = *q.IPv6Prefix
+		}
+		z.UpdatedAt = time.Now().UTC()
+		reply(w, http.StatusOK, z)
+	case http.MethodDelete:
+		for i := range s.zones {
+			if s.zones[i] == z {
+				s.zones = append(s.zones[:i], s.zones[i+1:]...)
+				break
+			}
+		}
+		delete(s.records, z.ID)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		fail(w, http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) serveRecords(w http.ResponseWriter, r *http.Request, z *Zone) {
+	if z == nil {
+		fail(w, http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		o := make([]Record, len(s.records[z.ID]))
+		for i, r := range s.records[z.ID] {
+			o[i] = *r
+		}
+		reply(w, http.StatusOK, o)
+	case http.MethodPost:
+		var q Record
+		if json.NewDecoder(r.Body).Decode(&q) != nil || !valid(&q) {
+			fail(w, http.StatusUnprocessableEntity)
+			return
+		}
+		reply(w, http.StatusOK, s.add(z, &q))
+	default:
+		fail(w, http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) serveRecord(w http.ResponseWriter, r *http.Request, z *Zone, id string) {
+	if z == nil {
+		fail(w, http.StatusNotFound)
+		return
+	}
+	n, _ := strconv.ParseInt(id, 10, 64)
+	l := s.records[z.ID]
+	i := 0
+	for i < len(l) && l[i].ID != n {
+		i++
+	}
+	if i == len(l) {
+		fail(w, http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		reply(w, http.StatusOK, l[i])
+	case http.MethodPatch:
+		q := *l[i]
+		if json.NewDecoder(r.Body).Decode(&q) != nil || !valid(&q) {
+			fail(w, http.StatusUnprocessableEntity)
+			return
+		}
+		q.ID, q.ZoneID = l[i].ID, z.ID
+		q.ExpandedData = expand(&q, z)
+		*l[i] = q
+		z.UpdatedAt = time.Now().UTC()
+		reply(w, http.StatusOK, l[i])
+	case http.MethodDelete:
+		s.records[z.ID] = append(l[:i], l[i+1:]...)
+		z.UpdatedAt = time.Now().UTC()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		fail(w, http.StatusMethodNotAllowed)
+	}
+}
+
+func reply(w http.ResponseWriter, code int, v any) {
+	w.Header().Set(`Content-Type`, `application/json`)
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func fail(w http.ResponseWriter, code int) {
+	reply(w, code, map[string]string{`error`: http.StatusText(code)})
+}
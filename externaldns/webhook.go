@@ -0,0 +1,366 @@
+// Package externaldns implements the webhook provider API of Kubernetes
+// external-dns (https://kubernetes-sigs.github.io/external-dns/) on top of a
+// [libdynv6.Provider], so Services and Ingresses of a cluster can be
+// published into dynv6 zones. Run the Handler next to external-dns and start
+// it with --provider=webhook:
+//
+//	h := &externaldns.Handler{Provider: p, Zones: []string{`example.dynv6.net`}}
+//	log.Fatal(http.ListenAndServe(`localhost:8888`, h))
+package externaldns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ZxwyProject/dynv6"
+	"github.com/ZxwyProject/libdynv6"
+	"github.com/libdns/libdns"
+)
+
+// MediaType is the content type of webhook requests and responses.
+const MediaType = `application/external.dns.webhook+json;version=1`
+
+// Types are the record types published by default.
+var Types = []string{`A`, `AAAA`, `CNAME`, `TXT`, `MX`, `SRV`, `NS`}
+
+// Endpoint is a DNS name with its targets of one record type, as in
+// sigs.k8s.io/external-dns/endpoint.
+type Endpoint struct {
+	DNSName          string                     `json:"dnsName,omitempty"`
+	Targets          []string                   `json:"targets,omitempty"`
+	RecordType       string                     `json:"recordType,omitempty"`
+	SetIdentifier    string                     `json:"setIdentifier,omitempty"`
+	RecordTTL        int64                      `json:"recordTTL,omitempty"` // seconds
+	Labels           map[string]string          `json:"labels,omitempty"`
+	ProviderSpecific []ProviderSpecificProperty `json:"providerSpecific,omitempty"`
+}
+
+// ProviderSpecificProperty is an opaque property of an Endpoint.
+type ProviderSpecificProperty struct {
+	Name  string `json:"name,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+// Changes are the endpoints to create, update and delete in one
+// ApplyChanges call. UpdateOld[i] is replaced by UpdateNew[i].
+type Changes struct {
+	Create    []*Endpoint `json:"Create"`
+	UpdateOld []*Endpoint `json:"UpdateOld"`
+	UpdateNew []*Endpoint `json:"UpdateNew"`
+	Delete    []*Endpoint `json:"Delete"`
+}
+
+// DomainFilter tells external-dns which domains the webhook manages.
+type DomainFilter struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// Handler serves the webhook API:
+//
+//	GET  /                 negotiation, returns the DomainFilter
+//	GET  /records          all endpoints of the zones
+//	POST /records          apply Changes
+//	POST /adjustendpoints  normalize desired endpoints
+//
+// Each endpoint is stored as one record per target in the zone with the
+// longest matching name. TXT targets are unquoted when written and quoted
+// when read, so the TXT registry of external-dns works unchanged.
+type Handler struct {
+	Provider *libdynv6.Provider
+
+	// Zones to manage. All zones of the account if empty.
+	Zones []string
+
+	// Record types to publish. Types if empty.
+	Types []string
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var err error
+	switch {
+	case r.URL.Path == `/` && r.Method == http.MethodGet:
+		err = h.negotiate(w, r)
+	case r.URL.Path == `/records` && r.Method == http.MethodGet:
+		err = h.records(w, r)
+	case r.URL.Path == `/records` && r.Method == http.MethodPost:
+		err = h.applyChanges(w, r)
+	case r.URL.Path == `/adjustendpoints` && r.Method == http.MethodPost:
+		err = h.adjustEndpoints(w, r)
+	case r.URL.Path == `/healthz`:
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.NotFound(w, r)
+	}
+	if err != nil {
+		if dynv6.Debug {
+			dynv6.DbgLog.Println(`[Dynv6-debug/externaldns]`, r.Method, r.URL.Path, err)
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) negotiate(w http.ResponseWriter, r *http.Request) error {
+	z, err := h.zones(r.Context())
+	if err != nil {
+		return err
+	}
+	return reply(w, DomainFilter{Include: z})
+}
+
+func (h *Handler) records(w http.ResponseWriter, r *http.Request) error {
+	e, err := h.Records(r.Context())
+	if err != nil {
+		return err
+	}
+	return reply(w, e)
+}
+
+func (h *Handler) applyChanges(w http.ResponseWriter, r *http.Request) error {
+	var c Changes
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+	if err := h.ApplyChanges(r.Context(), &c); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h *Handler) adjustEndpoints(w http.ResponseWriter, r *http.Request) error {
+	var e []*Endpoint
+	if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+	return reply(w, AdjustEndpoints(e))
+}
+
+func reply(w http.ResponseWriter, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.Header().Set(`Content-Type`, MediaType)
+	w.Header().Set(`Vary`, `Content-Type`)
+	_, err = w.Write(b)
+	return err
+}
+
+// Records returns the endpoints of all zones, one per name and type.
+func (h *Handler) Records(ctx context.Context) ([]*Endpoint, error) {
+	zs, err := h.zones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	type key struct{ name, typ string }
+	var (
+		o []*Endpoint
+		m = make(map[key]*Endpoint)
+	)
+	for _, zone := range zs {
+		rs, err := h.Provider.GetRecords(ctx, zone)
+		if err != nil {
+			return nil, fmt.Errorf(`zone %s: %v`, zone, err)
+		}
+		for _, x := range rs {
+			rr := x.RR()
+			if !h.managed(rr.Type) {
+				continue
+			}
+			k := key{strings.ToLower(libdns.AbsoluteName(rr.Name, zone)), rr.Type}
+			e := m[k]
+			if e == nil {
+				e = &Endpoint{DNSName: k.name, RecordType: rr.Type, RecordTTL: int64(rr.TTL / time.Second)}
+				m[k] = e
+				o = append(o, e)
+			}
+			e.Targets = append(e.Targets, readTarget(rr.Type, rr.Data))
+		}
+	}
+	return o, nil
+}
+
+// ApplyChanges deletes, updates and creates the endpoints of c, in this
+// order. Updates replace the whole record set of the new name and type.
+func (h *Handler) ApplyChanges(ctx context.Context, c *Changes) error {
+	zs, err := h.zones(ctx)
+	if err != nil {
+		return err
+	}
+	if len(c.UpdateOld) != len(c.UpdateNew) {
+		return fmt.Errorf(`%d old and %d new endpoints to update`, len(c.UpdateOld), len(c.UpdateNew))
+	}
+
+	// Old endpoints are dropped unless their record set is replaced anyway.
+	kept := make(map[string]bool)
+	for _, e := range c.UpdateNew {
+		kept[endpointKey(e)] = true
+	}
+	del := append([]*Endpoint(nil), c.Delete...)
+	for _, e := range c.UpdateOld {
+		if !kept[endpointKey(e)] {
+			del = append(del, e)
+		}
+	}
+
+	for _, step := range []struct {
+		e []*Endpoint
+		f func(context.Context, string, []libdns.Record) ([]libdns.Record, error)
+	}{
+		{del, h.Provider.DeleteRecords},
+		{c.UpdateNew, h.Provider.SetRecords},
+		{c.Create, h.Provider.AppendRecords},
+	} {
+		g, err := h.group(zs, step.e)
+		if err != nil {
+			return err
+		}
+		for _, zone := range sortedKeys(g) {
+			if _, err := step.f(ctx, zone, g[zone]); err != nil {
+				return fmt.Errorf(`zone %s: %v`, zone, err)
+			}
+		}
+	}
+	return nil
+}
+
+// AdjustEndpoints returns e with names in lowercase and without a trailing
+// dot, the form returned by Records, so external-dns sees no spurious
+// differences. Host name targets lose their trailing dot likewise.
+func AdjustEndpoints(e []*Endpoint) []*Endpoint {
+	for _, x := range e {
+		x.DNSName = normalizeName(x.DNSName)
+		for i, t := range x.Targets {
+			x.Targets[i] = readTarget(x.RecordType, writeTarget(x.RecordType, t))
+		}
+	}
+	return e
+}
+
+// group returns the records of the endpoints e by zone.
+func (h *Handler) group(zones []string, e []*Endpoint) (map[string][]libdns.Record, error) {
+	g := make(map[string][]libdns.Record)
+	for _, x := range e {
+		if !h.managed(x.RecordType) {
+			return nil, fmt.Errorf(`%s %s: record type not supported`, x.DNSName, x.RecordType)
+		}
+		name := normalizeName(x.DNSName)
+		zone := zoneOf(zones, name)
+		if zone == `` {
+			return nil, fmt.Errorf(`%s: not in any zone`, x.DNSName)
+		}
+		rel := libdns.RelativeName(name+`.`, zone+`.`)
+		if rel == `@` {
+			rel = `` // as dynv6 names the apex
+		}
+		for _, t := range x.Targets {
+			g[zone] = append(g[zone], libdns.RR{
+				Name: rel,
+				TTL:  time.Duration(x.RecordTTL) * time.Second,
+				Type: x.RecordType,
+				Data: writeTarget(x.RecordType, t),
+			})
+		}
+	}
+	return g, nil
+}
+
+// zones returns the managed zone names, without trailing dot.
+func (h *Handler) zones(ctx context.Context) ([]string, error) {
+	if len(h.Zones) > 0 {
+		z := make([]string, len(h.Zones))
+		for i, s := range h.Zones {
+			z[i] = normalizeName(s)
+		}
+		return z, nil
+	}
+	l, err := h.Provider.ListZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	z := make([]string, len(l))
+	for i, x := range l {
+		z[i] = normalizeName(x.Name)
+	}
+	return z, nil
+}
+
+func (h *Handler) managed(t string) bool {
+	ts := h.Types
+	if len(ts) == 0 {
+		ts = Types
+	}
+	for _, x := range ts {
+		if strings.EqualFold(x, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// zoneOf returns the zone with the longest name name is in, or "".
+func zoneOf(zones []string, name string) string {
+	best := ``
+	for _, z := range zones {
+		if (name == z || strings.HasSuffix(name, `.`+z)) && len(z) > len(best) {
+			best = z
+		}
+	}
+	return best
+}
+
+func endpointKey(e *Endpoint) string {
+	return normalizeName(e.DNSName) + ` ` + strings.ToUpper(e.RecordType)
+}
+
+func normalizeName(s string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), `.`)
+}
+
+// writeTarget returns the record data for the target t of an endpoint:
+// TXT values are unquoted and host names are made fully qualified, as
+// external-dns never sends relative names.
+func writeTarget(typ, t string) string {
+	switch typ {
+	case `TXT`:
+		if len(t) >= 2 && t[0] == '"' && t[len(t)-1] == '"' {
+			return t[1 : len(t)-1]
+		}
+	case `CNAME`, `NS`, `MX`, `SRV`:
+		// The host name is the last field.
+		if t != `` && !strings.HasSuffix(t, `.`) {
+			return t + `.`
+		}
+	}
+	return t
+}
+
+// readTarget is the inverse of writeTarget.
+func readTarget(typ, data string) string {
+	switch typ {
+	case `TXT`:
+		return `"` + data + `"`
+	case `CNAME`, `NS`, `MX`, `SRV`:
+		if data != `.` && !strings.HasSuffix(data, ` .`) {
+			return strings.TrimSuffix(data, `.`)
+		}
+	}
+	return data
+}
+
+func sortedKeys(m map[string][]libdns.Record) []string {
+	k := make([]string, 0, len(m))
+	for s := range m {
+		k = append(k, s)
+	}
+	sort.Strings(k)
+	return k
+}
@@ -0,0 +1,86 @@
+package externaldns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ZxwyProject/libdynv6/dynv6test"
+)
+
+const zone = `example.dynv6.net`
+
+func TestApexEndpoint(t *testing.T) {
+	ctx := context.Background()
+	m := dynv6test.NewMemory(zone)
+	m.AddRecord(zone, dynv6test.Record{Type: `A`, Data: `192.0.2.1`})
+	m.AddRecord(zone, dynv6test.Record{Type: `TXT`, Data: `keep`})
+	h := &Handler{Provider: m.Provider, Zones: []string{zone}}
+
+	old := &Endpoint{DNSName: zone, RecordType: `A`, Targets: []string{`192.0.2.1`}}
+	upd := &Endpoint{DNSName: zone + `.`, RecordType: `A`, Targets: []string{`192.0.2.2`}}
+	err := h.ApplyChanges(ctx, &Changes{
+		UpdateOld: []*Endpoint{old},
+		UpdateNew: []*Endpoint{upd},
+		Create:    []*Endpoint{{DNSName: zone, RecordType: `MX`, Targets: []string{`10 mail.example.net`}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := apex(m)
+	if len(got) != 3 || got[`A`] != `192.0.2.2` || got[`MX`] != `mail.example.net.` || got[`TXT`] != `keep` {
+		t.Fatalf(`apex records after update: %v`, m.Records(zone))
+	}
+
+	// Applying the same creation again must not duplicate the record.
+	err = h.ApplyChanges(ctx, &Changes{Create: []*Endpoint{{DNSName: zone, RecordType: `MX`, Targets: []string{`10 mail.example.net`}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(m.Records(zone)); n != 3 {
+		t.Fatalf(`%d records after repeated create, want 3`, n)
+	}
+
+	err = h.ApplyChanges(ctx, &Changes{Delete: []*Endpoint{upd}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := apex(m); got[`A`] != `` {
+		t.Fatalf(`apex A record not deleted: %v`, m.Records(zone))
+	}
+}
+
+func TestRecordsServesApex(t *testing.T) {
+	m := dynv6test.NewMemory(zone)
+	m.AddRecord(zone, dynv6test.Record{Type: `A`, Data: `192.0.2.1`})
+	h := &Handler{Provider: m.Provider, Zones: []string{zone}}
+
+	req := httptest.NewRequest(http.MethodGet, `/records`, nil)
+	req.Header.Set(`Accept`, MediaType)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf(`GET /records: %d %s`, w.Code, w.Body)
+	}
+	var e []Endpoint
+	if err := json.NewDecoder(bytes.NewReader(w.Body.Bytes())).Decode(&e); err != nil {
+		t.Fatal(err)
+	}
+	if len(e) != 1 || e[0].DNSName != zone || e[0].RecordType != `A` || len(e[0].Targets) != 1 || e[0].Targets[0] != `192.0.2.1` {
+		t.Fatalf(`endpoints: %+v`, e)
+	}
+}
+
+// apex returns the data of the apex records of m by type.
+func apex(m *dynv6test.Memory) map[string]string {
+	o := make(map[string]string)
+	for _, r := range m.Records(zone) {
+		if r.Name == `` {
+			o[r.Type] = r.Data
+		}
+	}
+	return o
+}
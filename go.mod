@@ -2,6 +2,18 @@ module github.com/ZxwyProject/libdynv6
 
 go 1.20
 
-require github.com/libdns/libdns v1.1.0
+require (
+	github.com/libdns/libdns v1.1.0
+	github.com/miekg/dns v1.1.62
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require github.com/ZxwyProject/dynv6 v0.0.1
+
+require (
+	golang.org/x/mod v0.18.0 // indirect
+	golang.org/x/net v0.27.0 // indirect
+	golang.org/x/sync v0.7.0 // indirect
+	golang.org/x/sys v0.22.0 // indirect
+	golang.org/x/tools v0.22.0 // indirect
+)
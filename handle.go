@@ -0,0 +1,95 @@
+package libdynv6
+
+import (
+	"context"
+
+	"github.com/ZxwyProject/dynv6"
+	"github.com/libdns/libdns"
+)
+
+// ZoneHandle is a [Provider] bound to a single zone. The zone is resolved
+// once and its ID reused by all calls, saving a request per call.
+type ZoneHandle struct {
+	p    *Provider
+	name string
+}
+
+// Zone returns a handle for the zone name. The zone is not resolved before
+// the first call, so it must exist by then.
+func (p *Provider) Zone(name string) *ZoneHandle {
+	return &ZoneHandle{p, name}
+}
+
+// Name returns the zone name.
+func (h *ZoneHandle) Name() string { return h.name }
+
+// records returns the cached zone and its records. The zone is dropped from
+// the cache on errors, so it is resolved again next time.
+func (h *ZoneHandle) records(ctx context.Context) (*dynv6.Zone, []dynv6.Record, error) {
+	h.p.o.Do(h.p.init)
+	z, err := h.p.zoneCached(ctx, h.name)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := h.p.recordsIn(ctx, h.name, z)
+	if err != nil {
+		h.p.uncache(h.name)
+		return nil, nil, err
+	}
+	return z, r, nil
+}
+
+// Get returns all records of the zone, see [Provider.GetRecords].
+func (h *ZoneHandle) Get(ctx context.Context) ([]libdns.Record, error) {
+	_, r, err := h.records(ctx)
+	if err != nil {
+		return nil, err
+	}
+	o := make([]libdns.Record, len(r))
+	for i := range r {
+		o[i] = h.p.libdnsRecord(&r[i])
+	}
+	return o, nil
+}
+
+// Append creates records, see [Provider.AppendRecords].
+func (h *ZoneHandle) Append(ctx context.Context, records ...libdns.Record) ([]libdns.Record, error) {
+	unlock, err := h.p.lockZone(ctx, h.name)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	z, r, err := h.records(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return h.p.appendRecords(ctx, h.name, z, r, records)
+}
+
+// Set replaces RRsets, see [Provider.SetRecords].
+func (h *ZoneHandle) Set(ctx context.Context, records ...libdns.Record) ([]libdns.Record, error) {
+	unlock, err := h.p.lockZone(ctx, h.name)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	z, r, err := h.records(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return h.p.setRecordsIn(ctx, h.name, z, r, records)
+}
+
+// Delete deletes records, see [Provider.DeleteRecords].
+func (h *ZoneHandle) Delete(ctx context.Context, records ...libdns.Record) ([]libdns.Record, error) {
+	unlock, err := h.p.lockZone(ctx, h.name)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	z, r, err := h.records(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return h.p.deleteRecords(ctx, h.name, z, r, records)
+}
@@ -0,0 +1,98 @@
+package ipdetect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Command is an AddressSource running an external command, e.g. to query
+// OpenWrt:
+//
+//	&ipdetect.Command{Args: []string{`ubus`, `call`, `network.interface.wan`, `status`}}
+//	&ipdetect.Command{Prefix: []string{`uci`, `get`, `network.wan6.ip6prefix`}}
+//
+// The output is scanned for addresses and prefixes in any format, JSON
+// included: the first public IPv4 address, the first public IPv6 address
+// and the first IPv6 prefix shorter than /128 are used.
+type Command struct {
+	Args []string // command for all addresses
+
+	// Commands for single families, used instead of Args if set.
+	IPv4   []string
+	IPv6   []string
+	Prefix []string
+
+	Timeout time.Duration // per run, defaults to 10s
+}
+
+func (c *Command) Detect(ctx context.Context, f Family) (netip.Addr, error) {
+	if f == IPv4 {
+		return c.CurrentIPv4(ctx)
+	}
+	return c.CurrentIPv6(ctx)
+}
+
+func (c *Command) CurrentIPv4(ctx context.Context) (netip.Addr, error) {
+	a, _, err := c.scan(ctx, c.IPv4, IPv4)
+	return a, err
+}
+
+func (c *Command) CurrentIPv6(ctx context.Context) (netip.Addr, error) {
+	a, _, err := c.scan(ctx, c.IPv6, IPv6)
+	return a, err
+}
+
+func (c *Command) CurrentPrefix(ctx context.Context) (netip.Prefix, error) {
+	_, p, err := c.scan(ctx, c.Prefix, 0)
+	return p, err
+}
+
+// scan runs args, or Args if empty, and returns the first address of family
+// f, or with f == 0 the first IPv6 prefix, in its output.
+func (c *Command) scan(ctx context.Context, args []string, f Family) (netip.Addr, netip.Prefix, error) {
+	if len(args) == 0 {
+		args = c.Args
+	}
+	if len(args) == 0 {
+		return netip.Addr{}, netip.Prefix{}, errors.New(`command: no command configured`)
+	}
+	t := c.Timeout
+	if t <= 0 {
+		t = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, t)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).Output()
+	if err != nil {
+		return netip.Addr{}, netip.Prefix{}, fmt.Errorf(`%s: %v`, args[0], err)
+	}
+
+	words := strings.FieldsFunc(string(out), func(r rune) bool {
+		return !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F' || r == '.' || r == ':' || r == '/')
+	})
+	for _, w := range words {
+		if f == 0 {
+			if p, err := netip.ParsePrefix(w); err == nil && p.Addr().Is6() && p.Bits() < 128 {
+				if _, err := check(p.Addr(), IPv6); err == nil {
+					return netip.Addr{}, p.Masked(), nil
+				}
+			}
+			continue
+		}
+		if a, err := netip.ParseAddr(w); err == nil {
+			if a, err := check(a, f); err == nil {
+				return a, netip.Prefix{}, nil
+			}
+		}
+	}
+	what := f.String()
+	if f == 0 {
+		what = `IPv6 prefix`
+	}
+	return netip.Addr{}, netip.Prefix{}, fmt.Errorf(`%s: %w`, args[0], notFound(what))
+}
@@ -0,0 +1,73 @@
+package ipdetect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Default HTTPS echo services, returning the client address as plain text.
+const (
+	DefaultURL4 = `https://ipv4.icanhazip.com`
+	DefaultURL6 = `https://ipv6.icanhazip.com`
+)
+
+// HTTP detects addresses with an HTTPS echo service. Connections are forced
+// to the requested family, so dual-stack services work as well.
+type HTTP struct {
+	URL4 string // defaults to DefaultURL4
+	URL6 string // defaults to DefaultURL6
+
+	Transport *http.Transport // cloned per family; defaults to http.DefaultTransport
+}
+
+func (h *HTTP) Detect(ctx context.Context, f Family) (netip.Addr, error) {
+	u, network := h.URL4, `tcp4`
+	if f == IPv6 {
+		u, network = h.URL6, `tcp6`
+	}
+	if u == `` {
+		u = DefaultURL4
+		if f == IPv6 {
+			u = DefaultURL6
+		}
+	}
+
+	t := h.Transport
+	if t == nil {
+		t = http.DefaultTransport.(*http.Transport)
+	}
+	t = t.Clone()
+	d := net.Dialer{}
+	t.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+		return d.DialContext(ctx, network, addr)
+	}
+	defer t.CloseIdleConnections()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	resp, err := (&http.Client{Transport: t}).Do(req)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return netip.Addr{}, fmt.Errorf(`%s: %s`, u, resp.Status)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	a, err := netip.ParseAddr(strings.TrimSpace(string(b)))
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf(`%s: %v`, u, err)
+	}
+	return check(a, f)
+}
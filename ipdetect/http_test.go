@@ -0,0 +1,40 @@
+package ipdetect
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTP(t *testing.T) {
+	for _, c := range []struct {
+		status int
+		body   string
+		want   string // empty if detection fails
+		shared bool
+	}{
+		{200, "203.0.113.1\n", `203.0.113.1`, false},
+		{200, `10.0.0.1`, ``, false},
+		{200, `100.64.0.1`, ``, true},
+		{200, `2001:db8::1`, ``, false},
+		{200, `<html>`, ``, false},
+		{503, `203.0.113.1`, ``, false},
+	} {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(c.status)
+			io.WriteString(w, c.body)
+		}))
+		h := &HTTP{URL4: s.URL}
+		a, err := h.Detect(context.Background(), IPv4)
+		s.Close()
+		if c.want == `` && err == nil || c.want != `` && (err != nil || a.String() != c.want) {
+			t.Errorf(`%d %q: %v, %v, want %q`, c.status, c.body, a, err, c.want)
+		}
+		if errors.Is(err, ErrShared) != c.shared {
+			t.Errorf(`%d %q: %v, shared %v`, c.status, c.body, err, c.shared)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package ipdetect
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+// Kinds of IPv6 addresses, see [Addr6.Kind].
+const (
+	KindStatic    = `static`    // configured manually
+	KindEUI64     = `eui64`     // SLAAC with an interface ID derived from the MAC address
+	KindStable    = `stable`    // other stable addresses, e.g. RFC 7217 stable-privacy or DHCPv6
+	KindTemporary = `temporary` // RFC 4941 privacy address, changes regularly
+	KindUnknown   = `unknown`   // not EUI-64, without address flags to tell more
+)
+
+// DefaultPrefer is the preference order of IPv6 address kinds used if
+// Interface.Prefer is empty.
+var DefaultPrefer = []string{KindStatic, KindEUI64, KindStable, KindUnknown}
+
+// Interface detects addresses assigned to a local network interface,
+// for hosts which are directly connected (IPv6, or IPv4 without NAT).
+//
+// IPv6 link-local, deprecated and tentative addresses are skipped, and the
+// address whose kind comes first in Prefer is used, so the published
+// address does not churn with every new temporary (RFC 4941) address.
+// Address flags are only available on Linux; on other systems addresses
+// are either EUI-64 or of unknown kind.
+type Interface struct {
+	Name string // interface name; all interfaces if empty
+
+	// Address kinds (KindStatic, ...) in order of preference. Kinds not
+	// listed are never used. Defaults to DefaultPrefer.
+	Prefer []string
+	// Also use temporary and deprecated IPv6 addresses, after those of
+	// the kinds in Prefer.
+	AllowTemporary bool
+	// Length of the delegated prefix returned by Prefix, defaults to 64.
+	PrefixLen int
+}
+
+// Addr6 is an IPv6 address assigned to an interface.
+type Addr6 struct {
+	Prefix        netip.Prefix // address with on-link prefix length
+	Temporary     bool         // RFC 4941 privacy address
+	Deprecated    bool         // preferred lifetime expired
+	Tentative     bool         // duplicate address detection pending or failed
+	Permanent     bool         // configured manually, without lifetime
+	StablePrivacy bool         // RFC 7217 stable-privacy address
+	flags         bool         // whether the flags above are known
+}
+
+// Kind returns the kind of the address, one of KindStatic, KindEUI64,
+// KindStable, KindTemporary or KindUnknown.
+func (a *Addr6) Kind() string {
+	switch {
+	case a.Temporary:
+		return KindTemporary
+	case a.Permanent:
+		return KindStatic
+	case isEUI64(a.Prefix.Addr()):
+		return KindEUI64
+	case a.StablePrivacy || a.flags:
+		return KindStable
+	}
+	return KindUnknown
+}
+
+// isEUI64 reports whether the interface ID of a is a modified EUI-64,
+// which has ff:fe in its middle (RFC 4291 appendix A).
+func isEUI64(a netip.Addr) bool {
+	b := a.As16()
+	return b[11] == 0xff && b[12] == 0xfe
+}
+
+func (i *Interface) Detect(ctx context.Context, f Family) (netip.Addr, error) {
+	if f == IPv6 {
+		a, err := i.addr6()
+		return a.Prefix.Addr(), err
+	}
+
+	l, err := i.interfaces()
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	for _, n := range l {
+		a, err := ifaceAddrs(&n)
+		if err != nil {
+			return netip.Addr{}, err
+		}
+		for _, p := range a {
+			if x, err := check(p.Addr(), f); err == nil {
+				return x, nil
+			}
+		}
+	}
+	return netip.Addr{}, fmt.Errorf(`%s: %w`, f, ErrNotFound)
+}
+
+// Prefix returns the delegated IPv6 prefix, derived from the selected
+// address and PrefixLen.
+func (i *Interface) Prefix(ctx context.Context) (netip.Prefix, error) {
+	a, err := i.addr6()
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return a.Prefix.Addr().Prefix(prefixLen(i.PrefixLen))
+}
+
+// CurrentIPv4 implements [AddressSource].
+func (i *Interface) CurrentIPv4(ctx context.Context) (netip.Addr, error) {
+	return i.Detect(ctx, IPv4)
+}
+
+// CurrentIPv6 implements [AddressSource].
+func (i *Interface) CurrentIPv6(ctx context.Context) (netip.Addr, error) {
+	return i.Detect(ctx, IPv6)
+}
+
+// CurrentPrefix implements [AddressSource], see [Interface.Prefix].
+func (i *Interface) CurrentPrefix(ctx context.Context) (netip.Prefix, error) {
+	return i.Prefix(ctx)
+}
+
+// Addrs6 returns all IPv6 addresses of the interface(s) with their flags.
+func (i *Interface) Addrs6() ([]Addr6, error) {
+	l, err := i.interfaces()
+	if err != nil {
+		return nil, err
+	}
+	return addrs6(l)
+}
+
+// addr6 returns the usable global IPv6 address of the most preferred kind.
+func (i *Interface) addr6() (Addr6, error) {
+	a, err := i.Addrs6()
+	if err != nil {
+		return Addr6{}, err
+	}
+	var (
+		best Addr6
+		rank = -1
+	)
+	for _, x := range a {
+		if x.Tentative || !i.AllowTemporary && x.Deprecated {
+			continue
+		}
+		r := i.rank(&x)
+		if r < 0 || rank >= 0 && r >= rank {
+			continue
+		}
+		if _, err := check(x.Prefix.Addr(), IPv6); err == nil {
+			best, rank = x, r
+		}
+	}
+	if rank < 0 {
+		return Addr6{}, fmt.Errorf(`%s: %w`, IPv6, ErrNotFound)
+	}
+	return best, nil
+}
+
+// rank returns the position of the kind of a in the preference order,
+// or -1 if it must not be used. Deprecated addresses come last.
+func (i *Interface) rank(a *Addr6) int {
+	p := i.Prefer
+	if len(p) == 0 {
+		p = DefaultPrefer
+	}
+	n := len(p) + 1
+	k := a.Kind()
+	r := -1
+	for j, x := range p {
+		if x == k {
+			r = j
+			break
+		}
+	}
+	if r < 0 && i.AllowTemporary && k == KindTemporary {
+		r = len(p)
+	}
+	if r >= 0 && a.Deprecated {
+		r += n
+	}
+	return r
+}
+
+// interfaces returns the named interface, or all up non-loopback interfaces.
+func (i *Interface) interfaces() ([]net.Interface, error) {
+	if i.Name != `` {
+		n, err := net.InterfaceByName(i.Name)
+		if err != nil {
+			return nil, err
+		}
+		return []net.Interface{*n}, nil
+	}
+
+	l, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	o := l[:0]
+	for _, n := range l {
+		if n.Flags&net.FlagUp != 0 && n.Flags&net.FlagLoopback == 0 {
+			o = append(o, n)
+		}
+	}
+	return o, nil
+}
+
+// ifaceAddrs returns the addresses of n with their on-link prefix length.
+func ifaceAddrs(n *net.Interface) ([]netip.Prefix, error) {
+	a, err := n.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	o := make([]netip.Prefix, 0, len(a))
+	for _, x := range a {
+		if ipn, ok := x.(*net.IPNet); ok {
+			ip, _ := netip.AddrFromSlice(ipn.IP)
+			ones, _ := ipn.Mask.Size()
+			o = append(o, netip.PrefixFrom(ip.Unmap(), ones))
+		}
+	}
+	return o, nil
+}
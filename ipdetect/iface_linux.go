@@ -0,0 +1,74 @@
+package ipdetect
+
+import (
+	"net"
+	"net/netip"
+	"syscall"
+	"unsafe"
+)
+
+// ifaFlags is the IFA_FLAGS netlink attribute, which carries the full
+// 32 bit flags on newer kernels.
+const ifaFlags = 8
+
+// ifaFStablePrivacy marks RFC 7217 addresses, missing in package syscall.
+const ifaFStablePrivacy = 0x800
+
+// addrs6 reads the IPv6 addresses of l including their flags over netlink.
+func addrs6(l []net.Interface) ([]Addr6, error) {
+	idx := make(map[uint32]bool, len(l))
+	for _, n := range l {
+		idx[uint32(n.Index)] = true
+	}
+
+	b, err := syscall.NetlinkRIB(syscall.RTM_GETADDR, syscall.AF_INET6)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := syscall.ParseNetlinkMessage(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var o []Addr6
+	for i := range msgs {
+		m := &msgs[i]
+		if m.Header.Type != syscall.RTM_NEWADDR || len(m.Data) < syscall.SizeofIfAddrmsg {
+			continue
+		}
+		ifa := (*syscall.IfAddrmsg)(unsafe.Pointer(&m.Data[0]))
+		if ifa.Family != syscall.AF_INET6 || !idx[ifa.Index] {
+			continue
+		}
+		attrs, err := syscall.ParseNetlinkRouteAttr(m)
+		if err != nil {
+			return nil, err
+		}
+
+		var ip netip.Addr
+		flags := uint32(ifa.Flags)
+		for _, a := range attrs {
+			switch a.Attr.Type {
+			case syscall.IFA_ADDRESS:
+				ip, _ = netip.AddrFromSlice(a.Value)
+			case ifaFlags:
+				if len(a.Value) >= 4 {
+					flags = *(*uint32)(unsafe.Pointer(&a.Value[0])) // native endian
+				}
+			}
+		}
+		if !ip.Is6() {
+			continue
+		}
+		o = append(o, Addr6{
+			Prefix:        netip.PrefixFrom(ip, int(ifa.Prefixlen)),
+			Temporary:     flags&syscall.IFA_F_TEMPORARY != 0,
+			Deprecated:    flags&syscall.IFA_F_DEPRECATED != 0,
+			Tentative:     flags&(syscall.IFA_F_TENTATIVE|syscall.IFA_F_DADFAILED) != 0,
+			Permanent:     flags&syscall.IFA_F_PERMANENT != 0,
+			StablePrivacy: flags&ifaFStablePrivacy != 0,
+			flags:         true,
+		})
+	}
+	return o, nil
+}
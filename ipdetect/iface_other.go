@@ -0,0 +1,22 @@
+//go:build !linux
+
+package ipdetect
+
+import "net"
+
+// addrs6 returns the IPv6 addresses of l. Address flags are not available.
+func addrs6(l []net.Interface) ([]Addr6, error) {
+	var o []Addr6
+	for i := range l {
+		a, err := ifaceAddrs(&l[i])
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range a {
+			if p.Addr().Is6() {
+				o = append(o, Addr6{Prefix: p})
+			}
+		}
+	}
+	return o, nil
+}
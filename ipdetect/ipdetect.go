@@ -0,0 +1,127 @@
+// Package ipdetect detects the current public IPv4 and IPv6 addresses,
+// for publishing them through the update package.
+package ipdetect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+
+	"github.com/ZxwyProject/libdynv6/update"
+)
+
+// Family is an IP address family.
+type Family int
+
+const (
+	IPv4 Family = 4
+	IPv6 Family = 6
+)
+
+func (f Family) String() string {
+	return fmt.Sprintf(`IPv%d`, int(f))
+}
+
+// ErrNotFound is returned if a detector found no address of the family.
+var ErrNotFound = errors.New(`no address found`)
+
+// ErrShared is returned if the IPv4 address found is shared with other
+// customers of the ISP, behind carrier-grade NAT or DS-Lite, and thus not
+// reachable from the Internet.
+var ErrShared = errors.New(`shared address behind carrier-grade NAT`)
+
+// Shared IPv4 ranges: RFC 6598 carrier-grade NAT, RFC 6333 DS-Lite.
+var sharedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix(`100.64.0.0/10`),
+	netip.MustParsePrefix(`192.0.0.0/29`),
+}
+
+// IsShared reports whether a is in a range used for carrier-grade NAT or
+// DS-Lite, see ErrShared.
+func IsShared(a netip.Addr) bool {
+	a = a.Unmap()
+	for _, p := range sharedPrefixes {
+		if p.Contains(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// Detector detects the public address of a family.
+type Detector interface {
+	Detect(ctx context.Context, f Family) (netip.Addr, error)
+}
+
+// Chain tries its detectors in order and returns the first address found.
+// A detector failing with ErrShared ends the chain, since the others would
+// only find the public address of the NAT.
+type Chain []Detector
+
+func (c Chain) Detect(ctx context.Context, f Family) (netip.Addr, error) {
+	var errs []error
+	for _, d := range c {
+		a, err := d.Detect(ctx, f)
+		if err == nil {
+			return a, nil
+		}
+		if errors.Is(err, ErrShared) {
+			return netip.Addr{}, err
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return netip.Addr{}, ErrNotFound
+	}
+	return netip.Addr{}, errors.Join(errs...)
+}
+
+// Default is the detector used if none is configured: HTTPS echo services,
+// falling back to STUN.
+var Default Detector = Chain{&HTTP{}, &STUN{}}
+
+// Addrs detects the families fs (both if empty) with d and returns them for
+// an update. It fails only if none of the families could be detected.
+func Addrs(ctx context.Context, d Detector, fs ...Family) (update.Addrs, error) {
+	if d == nil {
+		d = Default
+	}
+	if len(fs) == 0 {
+		fs = []Family{IPv4, IPv6}
+	}
+	var o update.Addrs
+	var errs []error
+	for _, f := range fs {
+		a, err := d.Detect(ctx, f)
+		if err != nil {
+			errs = append(errs, fmt.Errorf(`detect %s: %w`, f, err))
+			continue
+		}
+		if f == IPv4 {
+			o.IPv4 = a.String()
+		} else {
+			o.IPv6 = a.String()
+		}
+	}
+	if len(errs) == len(fs) {
+		return o, errors.Join(errs...)
+	}
+	return o, nil
+}
+
+// check returns a if it is a valid public address of family f.
+func check(a netip.Addr, f Family) (netip.Addr, error) {
+	a = a.Unmap()
+	switch {
+	case !a.IsValid():
+		return a, ErrNotFound
+	case f == IPv4 && !a.Is4(), f == IPv6 && !a.Is6():
+		return netip.Addr{}, fmt.Errorf(`got %s, want %s`, a, f)
+	case IsShared(a):
+		return netip.Addr{}, fmt.Errorf(`%s: %w`, a, ErrShared)
+	case !a.IsGlobalUnicast() || a.IsPrivate():
+		return netip.Addr{}, fmt.Errorf(`%s is not a public address`, a)
+	}
+	return a, nil
+}
@@ -0,0 +1,88 @@
+package ipdetect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"testing"
+)
+
+// fixed detects the address a, or fails with err.
+type fixed struct {
+	a   string
+	err error
+}
+
+func (d fixed) Detect(context.Context, Family) (netip.Addr, error) {
+	if d.err != nil {
+		return netip.Addr{}, d.err
+	}
+	return netip.ParseAddr(d.a)
+}
+
+func TestCheck(t *testing.T) {
+	for _, c := range []struct {
+		a      string
+		f      Family
+		ok     bool
+		shared bool
+	}{
+		{`203.0.113.1`, IPv4, true, false},
+		{`::ffff:203.0.113.1`, IPv4, true, false},
+		{`2001:db8::1`, IPv6, true, false},
+		{`203.0.113.1`, IPv6, false, false},
+		{`10.0.0.1`, IPv4, false, false},
+		{`127.0.0.1`, IPv4, false, false},
+		{`fd00::1`, IPv6, false, false},
+		{`fe80::1`, IPv6, false, false},
+		{`100.64.0.1`, IPv4, false, true},
+		{`192.0.0.2`, IPv4, false, true},
+	} {
+		_, err := check(netip.MustParseAddr(c.a), c.f)
+		if (err == nil) != c.ok || errors.Is(err, ErrShared) != c.shared {
+			t.Errorf(`check(%s, %s) = %v`, c.a, c.f, err)
+		}
+	}
+	if _, err := check(netip.Addr{}, IPv4); err != ErrNotFound {
+		t.Errorf(`check of no address = %v, want ErrNotFound`, err)
+	}
+}
+
+func TestChain(t *testing.T) {
+	shared := fmt.Errorf(`100.64.0.1: %w`, ErrShared)
+	for _, c := range []struct {
+		name string
+		c    Chain
+		want string
+		err  error
+	}{
+		{`empty`, Chain{}, ``, ErrNotFound},
+		{`first`, Chain{fixed{a: `203.0.113.1`}, fixed{a: `203.0.113.2`}}, `203.0.113.1`, nil},
+		{`fallback`, Chain{fixed{err: ErrNotFound}, fixed{a: `203.0.113.2`}}, `203.0.113.2`, nil},
+		{`shared`, Chain{fixed{err: shared}, fixed{a: `203.0.113.2`}}, ``, ErrShared},
+		{`all fail`, Chain{fixed{err: ErrNotFound}, fixed{err: ErrNotFound}}, ``, ErrNotFound},
+	} {
+		a, err := c.c.Detect(context.Background(), IPv4)
+		if c.err != nil && !errors.Is(err, c.err) || c.err == nil && (err != nil || a.String() != c.want) {
+			t.Errorf(`%s: %v, %v, want %s, %v`, c.name, a, err, c.want, c.err)
+		}
+	}
+}
+
+func TestAddrs(t *testing.T) {
+	ctx := context.Background()
+	a, err := Addrs(ctx, fixed{a: `2001:db8::1`}, IPv6)
+	if err != nil || a.IPv6 != `2001:db8::1` || a.IPv4 != `` {
+		t.Errorf(`IPv6: %+v, %v`, a, err)
+	}
+	if _, err = Addrs(ctx, fixed{err: ErrNotFound}); !errors.Is(err, ErrNotFound) {
+		t.Errorf(`failing detector: %v, want ErrNotFound`, err)
+	}
+
+	// One family is enough.
+	d := SourceDetector(&DetectorSource{Detector: Chain{fixed{a: `203.0.113.1`}}})
+	if a, err = Addrs(ctx, d); err != nil || a.IPv4 != `203.0.113.1` {
+		t.Errorf(`source: %+v, %v`, a, err)
+	}
+}
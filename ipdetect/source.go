@@ -0,0 +1,84 @@
+package ipdetect
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+
+	"github.com/ZxwyProject/libdynv6/update"
+)
+
+// AddressSource provides the current public addresses and the delegated
+// IPv6 prefix, e.g. from a router. Methods return an error wrapping
+// ErrNotFound if the source knows no such address.
+//
+// Built-in sources are [Interface], [Command], [UPnP] and, for any
+// [Detector] like [HTTP], [DetectorSource].
+type AddressSource interface {
+	CurrentIPv4(ctx context.Context) (netip.Addr, error)
+	CurrentIPv6(ctx context.Context) (netip.Addr, error)
+	CurrentPrefix(ctx context.Context) (netip.Prefix, error)
+}
+
+// DetectorSource is an AddressSource backed by a Detector. The prefix is
+// derived from the IPv6 address.
+type DetectorSource struct {
+	Detector  Detector // defaults to Default
+	PrefixLen int      // length of the delegated prefix, defaults to 64
+}
+
+func (s *DetectorSource) detector() Detector {
+	if s.Detector == nil {
+		return Default
+	}
+	return s.Detector
+}
+
+func (s *DetectorSource) CurrentIPv4(ctx context.Context) (netip.Addr, error) {
+	return s.detector().Detect(ctx, IPv4)
+}
+
+func (s *DetectorSource) CurrentIPv6(ctx context.Context) (netip.Addr, error) {
+	return s.detector().Detect(ctx, IPv6)
+}
+
+func (s *DetectorSource) CurrentPrefix(ctx context.Context) (netip.Prefix, error) {
+	a, err := s.CurrentIPv6(ctx)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return a.Prefix(prefixLen(s.PrefixLen))
+}
+
+// SourceDetector returns a Detector backed by s, for use where a Detector
+// is expected, e.g. in a [Chain].
+func SourceDetector(s AddressSource) Detector {
+	return sourceDetector{s}
+}
+
+type sourceDetector struct{ s AddressSource }
+
+func (d sourceDetector) Detect(ctx context.Context, f Family) (netip.Addr, error) {
+	if f == IPv4 {
+		return d.s.CurrentIPv4(ctx)
+	}
+	return d.s.CurrentIPv6(ctx)
+}
+
+// SourceAddrs is like [Addrs], but asks the AddressSource s.
+func SourceAddrs(ctx context.Context, s AddressSource, fs ...Family) (update.Addrs, error) {
+	return Addrs(ctx, SourceDetector(s), fs...)
+}
+
+// prefixLen returns n, or the default length 64 of a delegated prefix.
+func prefixLen(n int) int {
+	if n == 0 {
+		return 64
+	}
+	return n
+}
+
+// notFound returns an error wrapping ErrNotFound for what.
+func notFound(what string) error {
+	return fmt.Errorf(`%s: %w`, what, ErrNotFound)
+}
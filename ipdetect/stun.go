@@ -0,0 +1,140 @@
+package ipdetect
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/netip"
+	"time"
+)
+
+// DefaultSTUNServer is the STUN server used if none is configured.
+const DefaultSTUNServer = `stun.l.google.com:19302`
+
+const (
+	stunMagic          = 0x2112A442
+	stunBindingRequest = 0x0001
+	stunBindingSuccess = 0x0101
+	stunMappedAddr     = 0x0001
+	stunXorMappedAddr  = 0x0020
+)
+
+var errSTUN = errors.New(`stun: malformed response`)
+
+// STUN detects addresses with a STUN binding request (RFC 5389).
+type STUN struct {
+	Server  string        // host:port, defaults to DefaultSTUNServer
+	Timeout time.Duration // defaults to 3s
+}
+
+func (s *STUN) Detect(ctx context.Context, f Family) (netip.Addr, error) {
+	server, network := s.Server, `udp4`
+	if server == `` {
+		server = DefaultSTUNServer
+	}
+	if f == IPv6 {
+		network = `udp6`
+	}
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = 3 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	d := net.Dialer{}
+	c, err := d.DialContext(ctx, network, server)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	defer c.Close()
+	if t, ok := ctx.Deadline(); ok {
+		c.SetDeadline(t)
+	}
+
+	req := make([]byte, 20)
+	binary.BigEndian.PutUint16(req[0:], stunBindingRequest)
+	binary.BigEndian.PutUint32(req[4:], stunMagic)
+	if _, err = rand.Read(req[8:20]); err != nil {
+		return netip.Addr{}, err
+	}
+	if _, err = c.Write(req); err != nil {
+		return netip.Addr{}, err
+	}
+
+	b := make([]byte, 1024)
+	n, err := c.Read(b)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	a, err := stunParse(b[:n], req[8:20])
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return check(a, f)
+}
+
+// stunParse returns the mapped address of a binding response.
+func stunParse(b, tid []byte) (netip.Addr, error) {
+	if len(b) < 20 || binary.BigEndian.Uint16(b[0:]) != stunBindingSuccess ||
+		binary.BigEndian.Uint32(b[4:]) != stunMagic || !bytes.Equal(b[8:20], tid) {
+		return netip.Addr{}, errSTUN
+	}
+	l := int(binary.BigEndian.Uint16(b[2:]))
+	if len(b) < 20+l {
+		return netip.Addr{}, errSTUN
+	}
+
+	var mapped netip.Addr
+	for a := b[20 : 20+l]; len(a) >= 4; {
+		t, n := binary.BigEndian.Uint16(a[0:]), int(binary.BigEndian.Uint16(a[2:]))
+		if len(a) < 4+n {
+			return netip.Addr{}, errSTUN
+		}
+		v := a[4 : 4+n]
+		switch t {
+		case stunXorMappedAddr:
+			ip, ok := stunAddr(v)
+			if !ok {
+				return netip.Addr{}, errSTUN
+			}
+			// XOR with magic cookie and transaction ID
+			x := append(binary.BigEndian.AppendUint32(nil, stunMagic), tid...)
+			for i := range ip {
+				ip[i] ^= x[i]
+			}
+			a, _ := netip.AddrFromSlice(ip)
+			return a, nil
+		case stunMappedAddr:
+			if ip, ok := stunAddr(v); ok {
+				mapped, _ = netip.AddrFromSlice(ip)
+			}
+		}
+		// attributes are padded to 4 bytes
+		n = (n + 3) &^ 3
+		if len(a) < 4+n {
+			break
+		}
+		a = a[4+n:]
+	}
+	if mapped.IsValid() {
+		return mapped, nil
+	}
+	return netip.Addr{}, errSTUN
+}
+
+// stunAddr returns the raw address of a (XOR-)MAPPED-ADDRESS value.
+func stunAddr(v []byte) ([]byte, bool) {
+	if len(v) < 4 {
+		return nil, false
+	}
+	ip := v[4:]
+	switch {
+	case v[1] == 0x01 && len(ip) == 4, v[1] == 0x02 && len(ip) == 16:
+		return append([]byte(nil), ip...), true
+	}
+	return nil, false
+}
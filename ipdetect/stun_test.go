@@ -0,0 +1,91 @@
+package ipdetect
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+var stunTID = []byte(`0123456789ab`)
+
+// stunAttr returns a (XOR-)MAPPED-ADDRESS attribute of typ for a.
+func stunAttr(typ uint16, a string, tid []byte) []byte {
+	ip := netip.MustParseAddr(a).AsSlice()
+	fam := byte(0x01)
+	if len(ip) == 16 {
+		fam = 0x02
+	}
+	if typ == stunXorMappedAddr {
+		x := append(binary.BigEndian.AppendUint32(nil, stunMagic), tid...)
+		for i := range ip {
+			ip[i] ^= x[i]
+		}
+	}
+	v := append([]byte{0, fam, 0, 0}, ip...)
+	b := binary.BigEndian.AppendUint16(nil, typ)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(v)))
+	return append(b, v...)
+}
+
+// stunResponse returns a binding success response carrying attrs.
+func stunResponse(tid []byte, attrs ...[]byte) []byte {
+	var body []byte
+	for _, a := range attrs {
+		body = append(body, a...)
+	}
+	b := binary.BigEndian.AppendUint16(nil, stunBindingSuccess)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(body)))
+	b = binary.BigEndian.AppendUint32(b, stunMagic)
+	b = append(b, tid...)
+	return append(b, body...)
+}
+
+func TestSTUNParse(t *testing.T) {
+	software := []byte{0x80, 0x22, 0, 5, 'x', 'y', 'z', 'z', 'y', 0, 0, 0} // padded
+	for _, c := range []struct {
+		name string
+		b    []byte
+		want string // empty for errSTUN
+	}{
+		{`xor IPv4`, stunResponse(stunTID, stunAttr(stunXorMappedAddr, `203.0.113.1`, stunTID)), `203.0.113.1`},
+		{`xor IPv6`, stunResponse(stunTID, stunAttr(stunXorMappedAddr, `2001:db8::1`, stunTID)), `2001:db8::1`},
+		{`mapped`, stunResponse(stunTID, stunAttr(stunMappedAddr, `203.0.113.2`, nil)), `203.0.113.2`},
+		{`xor preferred`, stunResponse(stunTID, stunAttr(stunMappedAddr, `203.0.113.2`, nil), software, stunAttr(stunXorMappedAddr, `203.0.113.1`, stunTID)), `203.0.113.1`},
+		{`no address`, stunResponse(stunTID, software), ``},
+		{`other transaction`, stunResponse([]byte(`ba9876543210`), stunAttr(stunXorMappedAddr, `203.0.113.1`, stunTID)), ``},
+		{`short`, stunResponse(stunTID)[:12], ``},
+		{`truncated`, stunResponse(stunTID, stunAttr(stunXorMappedAddr, `203.0.113.1`, stunTID))[:26], ``},
+		{`request`, append([]byte{0, 1}, stunResponse(stunTID)[2:]...), ``},
+	} {
+		a, err := stunParse(c.b, stunTID)
+		if c.want == `` && err != errSTUN || c.want != `` && (err != nil || a.String() != c.want) {
+			t.Errorf(`%s: %v, %v, want %q`, c.name, a, err, c.want)
+		}
+	}
+}
+
+func TestSTUN(t *testing.T) {
+	c, err := net.ListenPacket(`udp4`, `127.0.0.1:0`)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer c.Close()
+	go func() {
+		b := make([]byte, 1024)
+		n, from, err := c.ReadFrom(b)
+		if err != nil || n != 20 {
+			return
+		}
+		tid := b[8:20]
+		c.WriteTo(stunResponse(tid, stunAttr(stunXorMappedAddr, `203.0.113.1`, tid)), from)
+	}()
+
+	s := &STUN{Server: c.LocalAddr().String(), Timeout: 2 * time.Second}
+	a, err := s.Detect(context.Background(), IPv4)
+	if err != nil || a.String() != `203.0.113.1` {
+		t.Fatalf(`%v, %v`, a, err)
+	}
+}
@@ -0,0 +1,309 @@
+package ipdetect
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ssdpAddr is the SSDP multicast address for discovery.
+const ssdpAddr = `239.255.255.250:1900`
+
+// WAN connection service types, in order of preference.
+var upnpServices = []string{
+	`urn:schemas-upnp-org:service:WANIPConnection:2`,
+	`urn:schemas-upnp-org:service:WANIPConnection:1`,
+	`urn:schemas-upnp-org:service:WANPPPConnection:1`,
+}
+
+// UPnP is an AddressSource asking the router through UPnP-IGD. The IPv4
+// address comes from GetExternalIPAddress. IPv6 addresses and prefixes
+// are not part of IGD; they are read through the AVM extensions of
+// FRITZ!Box routers, and are not found on other routers.
+type UPnP struct {
+	// URL of the device description, e.g. http://fritz.box:49000/igddesc.xml.
+	// Discovered by SSDP if empty.
+	Location string
+
+	Timeout time.Duration // per request and for discovery, defaults to 3s
+	Client  *http.Client  // defaults to http.DefaultClient
+
+	mu      sync.Mutex
+	control string // control URL, resolved once
+	service string // service type at control
+}
+
+func (u *UPnP) Detect(ctx context.Context, f Family) (netip.Addr, error) {
+	if f == IPv4 {
+		return u.CurrentIPv4(ctx)
+	}
+	return u.CurrentIPv6(ctx)
+}
+
+func (u *UPnP) CurrentIPv4(ctx context.Context) (netip.Addr, error) {
+	v, err := u.call(ctx, `GetExternalIPAddress`, `NewExternalIPAddress`)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	a, err := netip.ParseAddr(v[0])
+	if err != nil {
+		return netip.Addr{}, notFound(IPv4.String())
+	}
+	return check(a, IPv4)
+}
+
+func (u *UPnP) CurrentIPv6(ctx context.Context) (netip.Addr, error) {
+	v, err := u.call(ctx, `X_AVM_DE_GetExternalIPv6Address`, `NewExternalIPv6Address`)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	a, err := netip.ParseAddr(v[0])
+	if err != nil {
+		return netip.Addr{}, notFound(IPv6.String())
+	}
+	return check(a, IPv6)
+}
+
+func (u *UPnP) CurrentPrefix(ctx context.Context) (netip.Prefix, error) {
+	v, err := u.call(ctx, `X_AVM_DE_GetIPv6Prefix`, `NewIPv6Prefix`, `NewPrefixLength`)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	p, err := netip.ParsePrefix(v[0] + `/` + v[1])
+	if err != nil || !p.Addr().Is6() {
+		return netip.Prefix{}, notFound(`IPv6 prefix`)
+	}
+	return p.Masked(), nil
+}
+
+func (u *UPnP) timeout() time.Duration {
+	if u.Timeout <= 0 {
+		return 3 * time.Second
+	}
+	return u.Timeout
+}
+
+func (u *UPnP) client() *http.Client {
+	if u.Client == nil {
+		return http.DefaultClient
+	}
+	return u.Client
+}
+
+// call invokes action on the WAN connection service and returns the values
+// of the output arguments out.
+func (u *UPnP) call(ctx context.Context, action string, out ...string) ([]string, error) {
+	ctrl, svc, err := u.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, u.timeout())
+	defer cancel()
+
+	body := `<?xml version="1.0"?>` +
+		`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">` +
+		`<s:Body><u:` + action + ` xmlns:u="` + svc + `"/></s:Body></s:Envelope>`
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ctrl, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(`Content-Type`, `text/xml; charset="utf-8"`)
+	req.Header.Set(`SOAPAction`, `"`+svc+`#`+action+`"`)
+	resp, err := u.client().Do(req)
+	if err != nil {
+		u.forget()
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// Unknown actions fail with a SOAP fault (500).
+		return nil, fmt.Errorf(`upnp %s: %s: %w`, action, resp.Status, ErrNotFound)
+	}
+
+	v := make([]string, len(out))
+	d := xml.NewDecoder(io.LimitReader(resp.Body, 1<<16))
+	for {
+		t, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf(`upnp %s: %v`, action, err)
+		}
+		if se, ok := t.(xml.StartElement); ok {
+			for i, name := range out {
+				if se.Name.Local == name {
+					var s string
+					if err := d.DecodeElement(&s, &se); err != nil {
+						return nil, fmt.Errorf(`upnp %s: %v`, action, err)
+					}
+					v[i] = strings.TrimSpace(s)
+				}
+			}
+		}
+	}
+	for i, s := range v {
+		if s == `` {
+			return nil, fmt.Errorf(`upnp %s: no %s: %w`, action, out[i], ErrNotFound)
+		}
+	}
+	return v, nil
+}
+
+// resolve returns the control URL and type of the WAN connection service,
+// discovering the router first if needed.
+func (u *UPnP) resolve(ctx context.Context) (string, string, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.control != `` {
+		return u.control, u.service, nil
+	}
+	loc := u.Location
+	if loc == `` {
+		var err error
+		if loc, err = u.discover(ctx); err != nil {
+			return ``, ``, err
+		}
+	}
+	ctrl, svc, err := u.describe(ctx, loc)
+	if err != nil {
+		return ``, ``, err
+	}
+	u.control, u.service = ctrl, svc
+	return ctrl, svc, nil
+}
+
+// forget drops the resolved service, e.g. after the router restarted.
+func (u *UPnP) forget() {
+	u.mu.Lock()
+	u.control, u.service = ``, ``
+	u.mu.Unlock()
+}
+
+// discover returns the location of the first Internet gateway device
+// answering an SSDP search.
+func (u *UPnP) discover(ctx context.Context) (string, error) {
+	c, err := net.ListenPacket(`udp4`, `:0`)
+	if err != nil {
+		return ``, err
+	}
+	defer c.Close()
+	dl := time.Now().Add(u.timeout())
+	if t, ok := ctx.Deadline(); ok && t.Before(dl) {
+		dl = t
+	}
+	c.SetDeadline(dl)
+	dst, err := net.ResolveUDPAddr(`udp4`, ssdpAddr)
+	if err != nil {
+		return ``, err
+	}
+
+	for _, st := range upnpServices {
+		msg := "M-SEARCH * HTTP/1.1\r\n" +
+			"HOST: " + ssdpAddr + "\r\n" +
+			"MAN: \"ssdp:discover\"\r\n" +
+			"MX: 2\r\n" +
+			"ST: " + st + "\r\n\r\n"
+		if _, err := c.WriteTo([]byte(msg), dst); err != nil {
+			return ``, err
+		}
+	}
+
+	b := make([]byte, 2048)
+	for {
+		n, _, err := c.ReadFrom(b)
+		if err != nil {
+			return ``, fmt.Errorf(`upnp: no gateway found: %w`, ErrNotFound)
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(b[:n])), nil)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+		if loc := resp.Header.Get(`Location`); loc != `` {
+			return loc, nil
+		}
+	}
+}
+
+// upnpDevice is the part of a device description listing services.
+type upnpDevice struct {
+	URLBase string   `xml:"URLBase"`
+	Device  upnpNode `xml:"device"`
+}
+
+type upnpNode struct {
+	Services []upnpService `xml:"serviceList>service"`
+	Devices  []upnpNode    `xml:"deviceList>device"`
+}
+
+type upnpService struct {
+	Type       string `xml:"serviceType"`
+	ControlURL string `xml:"controlURL"`
+}
+
+// describe fetches the device description at loc and returns the control
+// URL and type of its WAN connection service.
+func (u *UPnP) describe(ctx context.Context, loc string) (string, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, loc, nil)
+	if err != nil {
+		return ``, ``, err
+	}
+	resp, err := u.client().Do(req)
+	if err != nil {
+		return ``, ``, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ``, ``, fmt.Errorf(`%s: %s`, loc, resp.Status)
+	}
+	var d upnpDevice
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&d); err != nil {
+		return ``, ``, fmt.Errorf(`%s: %v`, loc, err)
+	}
+
+	var all []upnpService
+	var walk func(n upnpNode)
+	walk = func(n upnpNode) {
+		all = append(all, n.Services...)
+		for _, c := range n.Devices {
+			walk(c)
+		}
+	}
+	walk(d.Device)
+
+	base := loc
+	if d.URLBase != `` {
+		base = d.URLBase
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return ``, ``, err
+	}
+	for _, st := range upnpServices {
+		for _, s := range all {
+			if s.Type != st {
+				continue
+			}
+			c, err := b.Parse(s.ControlURL)
+			if err != nil {
+				return ``, ``, err
+			}
+			return c.String(), st, nil
+		}
+	}
+	return ``, ``, errors.New(`upnp: ` + loc + `: no WAN connection service`)
+}
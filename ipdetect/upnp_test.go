@@ -0,0 +1,97 @@
+package ipdetect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const upnpDesc = `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+<device>
+ <serviceList><service><serviceType>urn:schemas-upnp-org:service:Layer3Forwarding:1</serviceType><controlURL>/l3f</controlURL></service></serviceList>
+ <deviceList><device>
+  <serviceList><service><serviceType>urn:schemas-upnp-org:service:WANPPPConnection:1</serviceType><controlURL>/ppp</controlURL></service></serviceList>
+  <deviceList><device>
+   <serviceList><service><serviceType>urn:schemas-upnp-org:service:WANIPConnection:1</serviceType><controlURL>/ip</controlURL></service></serviceList>
+  </device></deviceList>
+ </device></deviceList>
+</device>
+</root>`
+
+// upnpRouter serves upnpDesc and answers SOAP actions at /ip with the
+// output arguments of results, or with a SOAP fault for other actions.
+func upnpRouter(t *testing.T, results map[string]string) *httptest.Server {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case `/desc.xml`:
+			io.WriteString(w, upnpDesc)
+		case `/ip`:
+			svc, action, _ := strings.Cut(strings.Trim(r.Header.Get(`SOAPAction`), `"`), `#`)
+			out, ok := results[action]
+			if svc != `urn:schemas-upnp-org:service:WANIPConnection:1` || !ok {
+				w.WriteHeader(http.StatusInternalServerError)
+				io.WriteString(w, `<s:Envelope><s:Body><s:Fault><faultstring>UPnPError</faultstring></s:Fault></s:Body></s:Envelope>`)
+				return
+			}
+			fmt.Fprintf(w, `<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><u:%sResponse xmlns:u="%s">%s</u:%[1]sResponse></s:Body></s:Envelope>`, action, svc, out)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestUPnP(t *testing.T) {
+	s := upnpRouter(t, map[string]string{
+		`GetExternalIPAddress`:            `<NewExternalIPAddress> 203.0.113.1 </NewExternalIPAddress>`,
+		`X_AVM_DE_GetExternalIPv6Address`: `<NewExternalIPv6Address>2001:db8:0:1::1</NewExternalIPv6Address><NewPrefixLength>64</NewPrefixLength>`,
+		`X_AVM_DE_GetIPv6Prefix`:          `<NewIPv6Prefix>2001:db8:0:100::</NewIPv6Prefix><NewPrefixLength>56</NewPrefixLength>`,
+	})
+	u := &UPnP{Location: s.URL + `/desc.xml`, Client: s.Client()}
+	ctx := context.Background()
+
+	if a, err := u.CurrentIPv4(ctx); err != nil || a.String() != `203.0.113.1` {
+		t.Errorf(`IPv4: %v, %v`, a, err)
+	}
+	if a, err := u.Detect(ctx, IPv6); err != nil || a.String() != `2001:db8:0:1::1` {
+		t.Errorf(`IPv6: %v, %v`, a, err)
+	}
+	if p, err := u.CurrentPrefix(ctx); err != nil || p.String() != `2001:db8:0:100::/56` {
+		t.Errorf(`prefix: %v, %v`, p, err)
+	}
+	if u.control != s.URL+`/ip` {
+		t.Errorf(`control URL %s, want the WANIPConnection service`, u.control)
+	}
+}
+
+func TestUPnPNotFound(t *testing.T) {
+	s := upnpRouter(t, map[string]string{
+		`GetExternalIPAddress`:            `<NewExternalIPAddress></NewExternalIPAddress>`,
+		`X_AVM_DE_GetExternalIPv6Address`: `<NewExternalIPv6Address>unknown</NewExternalIPv6Address>`,
+		// no X_AVM_DE_GetIPv6Prefix, as on routers other than FRITZ!Box
+	})
+	u := &UPnP{Location: s.URL + `/desc.xml`, Client: s.Client()}
+	ctx := context.Background()
+
+	if _, err := u.CurrentIPv4(ctx); !errors.Is(err, ErrNotFound) {
+		t.Errorf(`IPv4: %v, want ErrNotFound`, err)
+	}
+	if _, err := u.CurrentIPv6(ctx); !errors.Is(err, ErrNotFound) {
+		t.Errorf(`IPv6: %v, want ErrNotFound`, err)
+	}
+	if _, err := u.CurrentPrefix(ctx); !errors.Is(err, ErrNotFound) {
+		t.Errorf(`prefix: %v, want ErrNotFound`, err)
+	}
+
+	u = &UPnP{Location: s.URL + `/missing.xml`, Client: s.Client()}
+	if _, err := u.CurrentIPv4(ctx); err == nil {
+		t.Error(`missing description did not fail`)
+	}
+}
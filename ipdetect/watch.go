@@ -0,0 +1,68 @@
+package ipdetect
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultPollInterval is how often the addresses are compared by
+// WatchAddrs where change notifications are not available.
+const DefaultPollInterval = 10 * time.Second
+
+// WatchAddrs returns a channel which receives a value whenever addresses
+// of the local interfaces are added or removed, e.g. after the ISP
+// reconnected, until ctx is done. On Linux, netlink address notifications
+// are used; elsewhere, or if subscribing fails, the addresses are polled
+// every DefaultPollInterval. Changes arriving while a value is pending are
+// coalesced, so receivers never fall behind.
+func WatchAddrs(ctx context.Context) <-chan struct{} {
+	c := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case c <- struct{}{}:
+		default:
+		}
+	}
+	go func() {
+		if err := watchNetlink(ctx, notify); err != nil && ctx.Err() == nil {
+			pollAddrs(ctx, DefaultPollInterval, notify)
+		}
+	}()
+	return c
+}
+
+// pollAddrs calls notify whenever the interface addresses differ from
+// those seen interval earlier.
+func pollAddrs(ctx context.Context, interval time.Duration, notify func()) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	last := addrKey()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		if k := addrKey(); k != last {
+			last = k
+			notify()
+		}
+	}
+}
+
+// addrKey returns the addresses of all interfaces in a comparable form.
+func addrKey() string {
+	a, err := net.InterfaceAddrs()
+	if err != nil {
+		return ``
+	}
+	s := make([]string, len(a))
+	for i, x := range a {
+		s[i] = x.String()
+	}
+	sort.Strings(s)
+	return strings.Join(s, ` `)
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package ipdetect
+
+import (
+	"context"
+	"errors"
+)
+
+// watchNetlink is only available on Linux.
+func watchNetlink(ctx context.Context, notify func()) error {
+	return errors.New(`netlink not supported`)
+}
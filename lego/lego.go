@@ -0,0 +1,127 @@
+// Package lego adapts libdynv6 to the DNS-01 challenge provider interface
+// of github.com/go-acme/lego (challenge.Provider and
+// challenge.ProviderTimeout), without depending on lego itself:
+//
+//	p, err := lego.NewDNSProvider()
+//	...
+//	client.Challenge.SetDNS01Provider(p)
+package lego
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ZxwyProject/libdynv6"
+	"github.com/libdns/libdns"
+)
+
+// Environment variables read by NewDNSProvider.
+const (
+	EnvToken = `DYNV6_TOKEN`
+)
+
+// Defaults for Timeout.
+const (
+	DefaultPropagationTimeout = 2 * time.Minute
+	DefaultPollingInterval    = 2 * time.Second
+	DefaultTTL                = time.Minute
+)
+
+// DNSProvider presents DNS-01 challenges in dynv6 zones.
+type DNSProvider struct {
+	Provider *libdynv6.Provider
+
+	PropagationTimeout time.Duration // defaults to DefaultPropagationTimeout
+	PollingInterval    time.Duration // defaults to DefaultPollingInterval
+	TTL                time.Duration // defaults to DefaultTTL
+}
+
+// NewDNSProvider returns a provider using the token in DYNV6_TOKEN.
+// Only challenge records created by the same DNSProvider are ever cleaned
+// up, so records left by a crashed process have to be removed manually.
+func NewDNSProvider() (*DNSProvider, error) {
+	token := os.Getenv(EnvToken)
+	if token == `` {
+		return nil, errors.New(`dynv6: ` + EnvToken + ` is not set`)
+	}
+	return &DNSProvider{
+		Provider: &libdynv6.Provider{Token: token, OwnRecordsOnly: true},
+	}, nil
+}
+
+// Present creates the TXT record for the challenge of domain.
+func (d *DNSProvider) Present(domain, token, keyAuth string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout())
+	defer cancel()
+	zone, r, err := d.record(ctx, domain, keyAuth)
+	if err != nil {
+		return err
+	}
+	return wrap(d.Provider.AppendChallenge(ctx, zone, r))
+}
+
+// CleanUp deletes the TXT record for the challenge of domain.
+func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout())
+	defer cancel()
+	zone, r, err := d.record(ctx, domain, keyAuth)
+	if err != nil {
+		return err
+	}
+	return wrap(d.Provider.DeleteChallenge(ctx, zone, r))
+}
+
+// Timeout returns the timeout and polling interval lego uses to wait for
+// propagation.
+func (d *DNSProvider) Timeout() (timeout, interval time.Duration) {
+	timeout, interval = d.PropagationTimeout, d.PollingInterval
+	if timeout <= 0 {
+		timeout = DefaultPropagationTimeout
+	}
+	if interval <= 0 {
+		interval = DefaultPollingInterval
+	}
+	return timeout, interval
+}
+
+func (d *DNSProvider) timeout() time.Duration {
+	t, _ := d.Timeout()
+	return t
+}
+
+// record returns the zone and challenge record of domain.
+func (d *DNSProvider) record(ctx context.Context, domain, keyAuth string) (string, libdns.TXT, error) {
+	fqdn := `_acme-challenge.` + strings.TrimSuffix(strings.TrimPrefix(domain, `*.`), `.`)
+	zone, err := d.Provider.FindZone(ctx, fqdn)
+	if err != nil {
+		return ``, libdns.TXT{}, wrap(err)
+	}
+	ttl := d.TTL
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return zone, libdns.TXT{
+		Name: libdns.RelativeName(fqdn+`.`, zone+`.`),
+		TTL:  ttl,
+		Text: Value(keyAuth),
+	}, nil
+}
+
+// Value returns the TXT record value for keyAuth, as specified by RFC 8555.
+func Value(keyAuth string) string {
+	h := sha256.Sum256([]byte(keyAuth))
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
+
+func wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf(`dynv6: %w`, err)
+}
@@ -0,0 +1,75 @@
+package lego
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ZxwyProject/libdynv6"
+	"github.com/ZxwyProject/libdynv6/dynv6test"
+)
+
+func TestValue(t *testing.T) {
+	for _, c := range []struct{ keyAuth, want string }{
+		{``, `47DEQpj8HBSa-_TImW-5JCeuQeRkm5NMpJWZG3hSuFU`},
+		{`token.thumbprint`, `61rBZ_4knHblO0MNoxFsXZ_eTFUHum0B6IVRbhvUn5I`},
+		{`evaGxfADs6pSRb2LAv9IZf17Dt3juxGJ-PCt92wr-oA.nysaOBvbKeIp5p1HCP31JvwYn2bIjCzIxhlxh9wM8uk`, `M8qL6I9vfb40hdMu3S5SYlujV-qwDeFrFVjGZ13-X4Q`},
+	} {
+		if got := Value(c.keyAuth); got != c.want {
+			t.Errorf(`Value(%q) = %s, want %s`, c.keyAuth, got, c.want)
+		}
+	}
+}
+
+func TestPresentCleanUp(t *testing.T) {
+	const zone = `example.dynv6.net`
+	m := dynv6test.NewMemory(zone)
+	d := &DNSProvider{Provider: m.Provider}
+	for _, domain := range []string{`example.dynv6.net`, `*.www.example.dynv6.net.`} {
+		if err := d.Present(domain, `token`, `token.thumbprint`); err != nil {
+			t.Fatalf(`Present %s: %v`, domain, err)
+		}
+	}
+	want := map[string]bool{`_acme-challenge`: true, `_acme-challenge.www`: true}
+	r := m.Records(zone)
+	for _, x := range r {
+		if x.Type != `TXT` || !want[x.Name] || x.Data != Value(`token.thumbprint`) {
+			t.Errorf(`record %+v`, x)
+		}
+	}
+	if len(r) != len(want) {
+		t.Errorf(`%d records, want %d`, len(r), len(want))
+	}
+
+	for _, domain := range []string{`example.dynv6.net`, `*.www.example.dynv6.net.`} {
+		if err := d.CleanUp(domain, `token`, `token.thumbprint`); err != nil {
+			t.Fatalf(`CleanUp %s: %v`, domain, err)
+		}
+	}
+	if r = m.Records(zone); len(r) != 0 {
+		t.Errorf(`records left: %+v`, r)
+	}
+
+	if err := d.Present(`example.org`, `token`, `token.thumbprint`); !errors.Is(err, libdynv6.ErrZoneNotFound) {
+		t.Errorf(`Present outside the zones: %v, want ErrZoneNotFound`, err)
+	}
+}
+
+func TestNewDNSProvider(t *testing.T) {
+	t.Setenv(EnvToken, ``)
+	if _, err := NewDNSProvider(); err == nil {
+		t.Errorf(`no error without %s`, EnvToken)
+	}
+	t.Setenv(EnvToken, `tok`)
+	d, err := NewDNSProvider()
+	if err != nil || d.Provider.Token != `tok` || !d.Provider.OwnRecordsOnly {
+		t.Fatalf(`%+v, %v`, d, err)
+	}
+	if timeout, interval := d.Timeout(); timeout != DefaultPropagationTimeout || interval != DefaultPollingInterval {
+		t.Errorf(`Timeout() = %v, %v`, timeout, interval)
+	}
+	d.PropagationTimeout, d.PollingInterval = time.Minute, time.Second
+	if timeout, interval := d.Timeout(); timeout != time.Minute || interval != time.Second {
+		t.Errorf(`Timeout() = %v, %v`, timeout, interval)
+	}
+}
@@ -0,0 +1,57 @@
+package libdynv6
+
+import (
+	"context"
+
+	"github.com/libdns/libdns"
+)
+
+// SkippedRecord is a record [Provider.Migrate] did not copy.
+type SkippedRecord struct {
+	Record libdns.RR `json:"record"`
+	Reason string    `json:"reason"`
+}
+
+// MigrateResult reports the outcome of [Provider.Migrate].
+type MigrateResult struct {
+	Copied  []libdns.Record `json:"copied"`
+	Skipped []SkippedRecord `json:"skipped,omitempty"`
+}
+
+// Migrate copies all records of srcZone at any libdns provider src into
+// dstZone at dynv6, replacing the corresponding RRsets, see [Provider.SetRecords].
+// SOA and apex NS records, which dynv6 manages itself, and record types dynv6
+// does not support are skipped and reported.
+func (p *Provider) Migrate(ctx context.Context, src libdns.RecordGetter, srcZone, dstZone string) (*MigrateResult, error) {
+	r, err := src.GetRecords(ctx, srcZone)
+	if err != nil {
+		return nil, err
+	}
+
+	o := MigrateResult{}
+	in := make([]libdns.Record, 0, len(r))
+	for _, x := range r {
+		rr := x.RR()
+		switch {
+		case rr.Type == `SOA`:
+			o.Skipped = append(o.Skipped, SkippedRecord{rr, `managed by dynv6`})
+		case rr.Type == rtNS && (rr.Name == `@` || rr.Name == ``):
+			o.Skipped = append(o.Skipped, SkippedRecord{rr, `managed by dynv6`})
+		default:
+			if _, err := p.converter().FromLibdns(&rr); err != nil {
+				o.Skipped = append(o.Skipped, SkippedRecord{rr, err.Error()})
+				continue
+			}
+			in = append(in, rr)
+		}
+	}
+	if len(in) == 0 {
+		return &o, nil
+	}
+
+	o.Copied, err = p.SetRecords(ctx, dstZone, in)
+	if err != nil {
+		return &o, err
+	}
+	return &o, nil
+}
@@ -0,0 +1,276 @@
+package libdynv6
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OctoDNS zone files, as read and written by the YamlProvider of OctoDNS
+// (https://github.com/octodns/octodns), map record names to a record or a
+// list of records, one per type:
+//
+//	'':
+//	  - type: A
+//	    values: [192.0.2.1, 192.0.2.2]
+//	  - type: MX
+//	    value:
+//	      exchange: mail.example.com.
+//	      preference: 10
+//	www:
+//	  type: CNAME
+//	  ttl: 300
+//	  value: example.com.
+//
+// They are converted to and from a [DesiredState], so they can be applied
+// like one. Other keys of a record, like the octodns key holding options
+// for other providers, are ignored.
+
+// octoRecord is a record set of an OctoDNS zone file.
+type octoRecord struct {
+	Type   string      `yaml:"type"`
+	TTL    int         `yaml:"ttl,omitempty"`
+	Value  yaml.Node   `yaml:"value,omitempty"`
+	Values []yaml.Node `yaml:"values,omitempty"`
+}
+
+// octoValue holds the fields of structured values, e.g. of MX records.
+type octoValue struct {
+	Preference *int   `yaml:"preference"`
+	Priority   *int   `yaml:"priority"` // MX before OctoDNS 0.9, and SRV
+	Exchange   string `yaml:"exchange"`
+	Value      string `yaml:"value"` // MX before OctoDNS 0.9, and CAA
+	Weight     int    `yaml:"weight"`
+	Port       int    `yaml:"port"`
+	Target     string `yaml:"target"`
+	Flags      int    `yaml:"flags"`
+	Tag        string `yaml:"tag"`
+}
+
+// LoadOctoDNS reads the OctoDNS zone file of zone from r.
+func LoadOctoDNS(r io.Reader, zone string) (*DesiredState, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil && err != io.EOF {
+		return nil, fmt.Errorf(`octodns: %v`, err)
+	}
+	s := DesiredState{Zone: zoneName(zone)}
+	if len(doc.Content) == 0 {
+		return &s, s.validate()
+	}
+	m := doc.Content[0]
+	if m.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf(`octodns: line %d: expected a mapping of record names`, m.Line)
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		name := m.Content[i].Value
+		var rs []octoRecord
+		n := m.Content[i+1]
+		if n.Kind == yaml.SequenceNode {
+			err := n.Decode(&rs)
+			if err != nil {
+				return nil, fmt.Errorf(`octodns: %s: %v`, name, err)
+			}
+		} else {
+			var x octoRecord
+			if err := n.Decode(&x); err != nil {
+				return nil, fmt.Errorf(`octodns: %s: %v`, name, err)
+			}
+			rs = append(rs, x)
+		}
+
+		for _, x := range rs {
+			vs := x.Values
+			if x.Value.Kind != 0 {
+				vs = append([]yaml.Node{x.Value}, vs...)
+			}
+			if x.Type == `` || len(vs) == 0 {
+				return nil, fmt.Errorf(`octodns: %s: type and value or values are required`, name)
+			}
+			for j := range vs {
+				d, err := octoData(x.Type, &vs[j])
+				if err != nil {
+					return nil, fmt.Errorf(`octodns: %s %s: %v`, name, x.Type, err)
+				}
+				s.Records = append(s.Records, StateRecord{Name: name, Type: x.Type, Data: d, TTL: x.TTL})
+			}
+		}
+	}
+	return &s, s.validate()
+}
+
+// LoadOctoDNSFile is like [LoadOctoDNS], but reads the file name, whose
+// base name without .yaml or .yml is the zone, as with OctoDNS.
+func LoadOctoDNSFile(name string) (*DesiredState, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zone := filepath.Base(name)
+	for _, ext := range []string{`.yaml`, `.yml`} {
+		zone = strings.TrimSuffix(zone, ext)
+	}
+	return LoadOctoDNS(f, zone)
+}
+
+// octoData returns the record data of the OctoDNS value n.
+func octoData(t string, n *yaml.Node) (string, error) {
+	if n.Kind == yaml.ScalarNode {
+		switch t {
+		case `TXT`, `SPF`:
+			return strings.ReplaceAll(n.Value, `\;`, `;`), nil
+		}
+		return n.Value, nil
+	}
+	var v octoValue
+	if err := n.Decode(&v); err != nil {
+		return ``, err
+	}
+	switch t {
+	case `MX`:
+		pref, host := v.Preference, v.Exchange
+		if pref == nil {
+			pref = v.Priority
+		}
+		if host == `` {
+			host = v.Value
+		}
+		if pref == nil || host == `` {
+			return ``, fmt.Errorf(`line %d: preference and exchange are required`, n.Line)
+		}
+		return strconv.Itoa(*pref) + ` ` + host, nil
+	case `SRV`:
+		if v.Priority == nil || v.Target == `` {
+			return ``, fmt.Errorf(`line %d: priority, weight, port and target are required`, n.Line)
+		}
+		return fmt.Sprintf(`%d %d %d %s`, *v.Priority, v.Weight, v.Port, v.Target), nil
+	case `CAA`:
+		if v.Tag == `` {
+			return ``, fmt.Errorf(`line %d: flags, tag and value are required`, n.Line)
+		}
+		return fmt.Sprintf(`%d %s %q`, v.Flags, v.Tag, v.Value), nil
+	}
+	return ``, fmt.Errorf(`line %d: structured values not supported`, n.Line)
+}
+
+// WriteOctoDNS writes s to w as an OctoDNS zone file. Host names in the
+// data are written fully qualified, as OctoDNS requires, and each record
+// set takes the TTL of its first record.
+func (s *DesiredState) WriteOctoDNS(w io.Writer) error {
+	type key struct{ name, typ string }
+	var (
+		keys []key
+		sets = make(map[key][]StateRecord)
+	)
+	for _, r := range s.Records {
+		k := key{r.Name, strings.ToUpper(r.Type)}
+		if k.name == `@` {
+			k.name = ``
+		}
+		if _, ok := sets[k]; !ok {
+			keys = append(keys, k)
+		}
+		sets[k] = append(sets[k], r)
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+		return keys[i].typ < keys[j].typ
+	})
+
+	o := make(map[string][]map[string]interface{})
+	for _, k := range keys {
+		rs := sets[k]
+		m := map[string]interface{}{`type`: k.typ}
+		if t := rs[0].TTL; t > 0 {
+			m[`ttl`] = t
+		} else if s.TTL > 0 {
+			m[`ttl`] = s.TTL
+		}
+		vs := make([]interface{}, len(rs))
+		for i, r := range rs {
+			v, err := octoValueOf(s.Zone, k.typ, r.Data)
+			if err != nil {
+				return fmt.Errorf(`octodns: %s %s: %v`, r.Name, r.Type, err)
+			}
+			vs[i] = v
+		}
+		if len(vs) == 1 {
+			m[`value`] = vs[0]
+		} else {
+			m[`values`] = vs
+		}
+		o[k.name] = append(o[k.name], m)
+	}
+
+	// A name with a single record set is written without list.
+	out := make(map[string]interface{}, len(o))
+	for name, l := range o {
+		if len(l) == 1 {
+			out[name] = l[0]
+		} else {
+			out[name] = l
+		}
+	}
+	e := yaml.NewEncoder(w)
+	e.SetIndent(2)
+	if err := e.Encode(out); err != nil {
+		return err
+	}
+	return e.Close()
+}
+
+// octoValueOf returns the OctoDNS value of the record data d.
+func octoValueOf(zone, t, d string) (interface{}, error) {
+	switch t {
+	case `TXT`, `SPF`:
+		return strings.ReplaceAll(d, `;`, `\;`), nil
+	case `CNAME`, `NS`:
+		return canonicalTarget(zone, d), nil
+	case `MX`:
+		f := strings.Fields(d)
+		if len(f) != 2 {
+			return nil, fmt.Errorf(`invalid data %q`, d)
+		}
+		pref, err := strconv.Atoi(f[0])
+		if err != nil {
+			return nil, fmt.Errorf(`invalid preference %q`, f[0])
+		}
+		return map[string]interface{}{`preference`: pref, `exchange`: canonicalTarget(zone, f[1])}, nil
+	case `SRV`:
+		f := strings.Fields(d)
+		if len(f) != 4 {
+			return nil, fmt.Errorf(`invalid data %q`, d)
+		}
+		var n [3]int
+		for i := range n {
+			var err error
+			if n[i], err = strconv.Atoi(f[i]); err != nil {
+				return nil, fmt.Errorf(`invalid number %q`, f[i])
+			}
+		}
+		return map[string]interface{}{`priority`: n[0], `weight`: n[1], `port`: n[2], `target`: canonicalTarget(zone, f[3])}, nil
+	case `CAA`:
+		f := strings.SplitN(d, ` `, 3)
+		if len(f) != 3 {
+			return nil, fmt.Errorf(`invalid data %q`, d)
+		}
+		flags, err := strconv.Atoi(f[0])
+		if err != nil {
+			return nil, fmt.Errorf(`invalid flags %q`, f[0])
+		}
+		v := f[2]
+		if u, err := strconv.Unquote(v); err == nil {
+			v = u
+		}
+		return map[string]interface{}{`flags`: flags, `tag`: f[1], `value`: v}, nil
+	}
+	return d, nil
+}
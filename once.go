@@ -0,0 +1,42 @@
+package libdynv6
+
+import "sync"
+
+// retryOnce runs a function until it succeeds once. Unlike sync.Once, a
+// failed run is retried by the next call of Do, so transient failures do
+// not poison it. Callers arriving during a run wait for it and all get its
+// error.
+type retryOnce struct {
+	mu  sync.Mutex
+	ok  bool
+	cur *onceRun
+}
+
+type onceRun struct {
+	done chan struct{}
+	err  error
+}
+
+// Do runs f unless a previous run succeeded, and returns its error.
+func (o *retryOnce) Do(f func() error) error {
+	o.mu.Lock()
+	if o.ok {
+		o.mu.Unlock()
+		return nil
+	}
+	if r := o.cur; r != nil {
+		o.mu.Unlock()
+		<-r.done
+		return r.err
+	}
+	r := &onceRun{done: make(chan struct{})}
+	o.cur = r
+	o.mu.Unlock()
+
+	r.err = f()
+	o.mu.Lock()
+	o.ok, o.cur = r.err == nil, nil
+	o.mu.Unlock()
+	close(r.done)
+	return r.err
+}
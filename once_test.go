@@ -0,0 +1,70 @@
+package libdynv6
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// do calls o.Do(f) from n goroutines at once and returns their errors.
+func do(o *retryOnce, n int, f func() error) []error {
+	errs := make([]error, n)
+	var start, wg sync.WaitGroup
+	start.Add(1)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			start.Wait()
+			errs[i] = o.Do(f)
+		}(i)
+	}
+	start.Done()
+	wg.Wait()
+	return errs
+}
+
+func TestRetryOnceConcurrent(t *testing.T) {
+	var o retryOnce
+	var runs int32
+	errs := do(&o, 32, func() error {
+		atomic.AddInt32(&runs, 1)
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if runs != 1 {
+		t.Fatalf(`ran %d times, want 1`, runs)
+	}
+	if err := o.Do(func() error { return errors.New(`ran again`) }); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRetryOnceRetries(t *testing.T) {
+	var o retryOnce
+	failed := errors.New(`failed`)
+	var runs int32
+	errs := do(&o, 32, func() error {
+		atomic.AddInt32(&runs, 1)
+		time.Sleep(10 * time.Millisecond)
+		return failed
+	})
+	for _, err := range errs {
+		if err != failed {
+			t.Fatalf(`%v, want %v`, err, failed)
+		}
+	}
+	if err := o.Do(func() error { return nil }); err != nil {
+		t.Fatalf(`failed run not retried: %v`, err)
+	}
+	if runs == 32 {
+		t.Fatal(`callers during a run did not wait for it`)
+	}
+}
@@ -0,0 +1,31 @@
+package libdynv6
+
+// Records created by a Provider instance are tracked by zone and record ID,
+// see [Provider.OwnRecordsOnly]. A record keeps its ID when it is updated.
+
+func ownKey(zone, id string) string {
+	return zoneName(zone) + `/` + id
+}
+
+// adopt marks the record id in zone as created by p.
+func (p *Provider) adopt(zone, id string) {
+	p.om.Lock()
+	defer p.om.Unlock()
+	if p.own == nil {
+		p.own = make(map[string]bool)
+	}
+	p.own[ownKey(zone, id)] = true
+}
+
+func (p *Provider) disown(zone, id string) {
+	p.om.Lock()
+	defer p.om.Unlock()
+	delete(p.own, ownKey(zone, id))
+}
+
+// owned reports whether the record id in zone was created by p.
+func (p *Provider) owned(zone, id string) bool {
+	p.om.Lock()
+	defer p.om.Unlock()
+	return p.own[ownKey(zone, id)]
+}
@@ -0,0 +1,183 @@
+package libdynv6
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ZxwyProject/dynv6"
+	"github.com/ZxwyProject/libdynv6/diff"
+	"github.com/libdns/libdns"
+)
+
+// Change types of a [Plan].
+const (
+	ChangeCreate = `create`
+	ChangeUpdate = `update`
+	ChangeDelete = `delete`
+)
+
+// Change is a single record mutation of a [Plan].
+type Change struct {
+	Type   string     `json:"type"`          // ChangeCreate, ChangeUpdate or ChangeDelete
+	Record libdns.RR  `json:"record"`        // desired record, or the record to delete
+	Old    *libdns.RR `json:"old,omitempty"` // record replaced by an update
+	ID     string     `json:"id,omitempty"`  // dynv6 record ID for updates and deletes
+}
+
+func (c *Change) String() string {
+	switch c.Type {
+	case ChangeCreate:
+		return `+ ` + rrString(&c.Record)
+	case ChangeDelete:
+		return `- ` + rrString(&c.Record)
+	default:
+		return `~ ` + rrString(c.Old) + ` => ` + c.Record.Data
+	}
+}
+
+func rrString(r *libdns.RR) string {
+	return r.Name + ` ` + r.Type + ` ` + r.Data
+}
+
+// Plan is the set of changes needed to reach a desired state of a zone.
+type Plan struct {
+	Zone    string   `json:"zone"`
+	Changes []Change `json:"changes"`
+}
+
+// String formats the plan for review, one change per line.
+func (pl *Plan) String() string {
+	if len(pl.Changes) == 0 {
+		return `no changes`
+	}
+	var b strings.Builder
+	for i := range pl.Changes {
+		b.WriteString(pl.Changes[i].String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Plan returns the changes [Provider.SetRecords] would perform for desired
+// without executing them: for each RRset (name and type) in desired, the
+// records of that RRset in the zone are replaced by the desired ones.
+// Records of other RRsets are not affected.
+func (p *Provider) Plan(ctx context.Context, zone string, desired []libdns.Record) (*Plan, error) {
+	p.o.Do(p.init)
+	_, r, err := p.records(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	return planRRsets(p.converter(), zone, r, desired)
+}
+
+// Apply executes the changes of pl in order: updates, creates, then deletes,
+// so that an RRset never becomes empty in between. It returns the changes
+// applied before an error occurred. With an OwnerID, nothing is applied if
+// a change touches an RRset not owned, see [Provider.OwnerID].
+func (p *Provider) Apply(ctx context.Context, pl *Plan) ([]Change, error) {
+	p.o.Do(p.init)
+	unlock, err := p.lockZone(ctx, pl.Zone)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	z, err := p.zone(ctx, pl.Zone)
+	if err != nil {
+		return nil, err
+	}
+	return p.applyPlan(ctx, z, nil, pl)
+}
+
+// applyPlan applies pl to z, whose records are r, or read if nil.
+func (p *Provider) applyPlan(ctx context.Context, z *dynv6.Zone, r []dynv6.Record, pl *Plan) ([]Change, error) {
+	return p.guarded(ctx, pl.Zone, z, r, pl.Changes, func() ([]Change, error) {
+		o := make([]Change, 0, len(pl.Changes))
+		for _, t := range []string{ChangeUpdate, ChangeCreate, ChangeDelete} {
+			for i := range pl.Changes {
+				c := &pl.Changes[i]
+				if c.Type != t {
+					continue
+				}
+				if err := p.applyChange(ctx, pl.Zone, z, c); err != nil {
+					return o, err
+				}
+				o = append(o, *c)
+			}
+		}
+		return o, nil
+	})
+}
+
+func (p *Provider) applyChange(ctx context.Context, zone string, z *dynv6.Zone, c *Change) error {
+	if c.Type == ChangeDelete {
+		return p.recordDel(ctx, zone, z, c.ID, &c.Record)
+	}
+	dr, err := p.converter().FromLibdns(&c.Record)
+	if err != nil {
+		return err
+	}
+	if c.Type == ChangeCreate {
+		_, err = p.recordAdd(ctx, zone, z, &c.Record, dr)
+	} else {
+		_, err = p.recordUpd(ctx, zone, z, c.ID, &c.Record, dr)
+	}
+	return err
+}
+
+// planRRsets computes the changes replacing the RRsets of desired in r.
+func planRRsets(c RecordConverter, zone string, r []dynv6.Record, desired []libdns.Record) (*Plan, error) {
+	cur, want, err := diffInputs(c, zone, r, desired)
+	if err != nil {
+		return nil, err
+	}
+	return &Plan{Zone: zone, Changes: planChanges(r, desired, diff.RRsets(cur, want))}, nil
+}
+
+// diffInputs converts r and desired to the notation of c with canonical
+// target names, so equal records have equal data.
+func diffInputs(c RecordConverter, zone string, r []dynv6.Record, desired []libdns.Record) (cur, want []libdns.Record, err error) {
+	cur = make([]libdns.Record, len(r))
+	for i := range r {
+		x := r[i]
+		x.Data = canonicalData(zone, x.Type, x.Data)
+		cur[i] = convertRecord(c, &x)
+	}
+	want = make([]libdns.Record, len(desired))
+	for i, d := range desired {
+		rr := d.RR()
+		dr, err := c.FromLibdns(&rr)
+		if err != nil {
+			return nil, nil, fmt.Errorf(`%s %s: %v`, rr.Name, rr.Type, err)
+		}
+		want[i] = convertRecord(c, dryRecord(canonicalReq(zone, dr)))
+	}
+	return cur, want, nil
+}
+
+// planChanges converts the diff ops of r and desired to changes.
+func planChanges(r []dynv6.Record, desired []libdns.Record, ops []diff.Op) []Change {
+	o := make([]Change, len(ops))
+	for i, op := range ops {
+		switch op.Kind {
+		case diff.Create:
+			o[i] = Change{Type: ChangeCreate, Record: desired[op.To].RR()}
+		case diff.Update:
+			o[i] = Change{Type: ChangeUpdate, Record: desired[op.To].RR(), Old: op.Old, ID: string(r[op.From].ID)}
+		default:
+			o[i] = Change{Type: ChangeDelete, Record: op.Record, ID: string(r[op.From].ID)}
+		}
+	}
+	return o
+}
+
+// recordEqual reports whether the existing record r has the content of q.
+// The data of q may be given as written or as expanded by dynv6, e.g. a
+// prefix-relative AAAA record "::1" also matches its full address.
+func recordEqual(r *dynv6.Record, q *dynv6.RecordReq) bool {
+	return r.Name == q.Name && r.Type == q.Type &&
+		(r.Data == q.Data || (r.ExpandedData != `` && r.ExpandedData == q.Data)) &&
+		r.Priority == q.Priority && r.Weight == q.Weight && r.Port == q.Port &&
+		r.Flags == q.Flags && r.Tag == q.Tag
+}
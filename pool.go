@@ -0,0 +1,123 @@
+package libdynv6
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/ZxwyProject/dynv6"
+)
+
+// clientKey identifies the clients which Providers can share: same token
+// and same client configuration.
+type clientKey struct {
+	token, baseURL string
+	httpClient     *http.Client
+	dump           bool
+}
+
+// sharedClient is a client together with the state belonging to its
+// account, shared by all Providers of the process with the same clientKey.
+type sharedClient struct {
+	*dynv6.Client
+	key clientKey
+	n   int          // Providers using it, guarded by pool.mu
+	rl  *rateLimiter // shared by all clients of the account, guarded by pool.mu
+
+	zm sync.RWMutex           // for zc
+	zc map[string]*dynv6.Zone // zone cache by name
+}
+
+// limitKey identifies an account, whose clients share a rate limit.
+type limitKey struct{ token, baseURL string }
+
+// pool holds the shared clients and the rate limiters by account.
+var pool struct {
+	mu sync.Mutex
+	m  map[clientKey]*sharedClient
+	rl map[limitKey]*rateLimiter
+}
+
+// sharedClient returns the shared client for token, creating it with the
+// configured transports if needed. Release it with releaseClient.
+func (p *Provider) sharedClient(token string) *sharedClient {
+	k := clientKey{token, ExpandEnv(p.BaseURL), p.HTTPClient, p.DebugDump}
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if c := pool.m[k]; c != nil {
+		c.n++
+		return c
+	}
+
+	c := &sharedClient{Client: dynv6.NewClient(token), key: k, n: 1}
+	if k.baseURL != `` {
+		c.BaseURL = k.baseURL
+	}
+	if p.HTTPClient != nil {
+		c.HTTPClient = p.HTTPClient
+	}
+	rl := pool.rl[c.limitKey()]
+	if rl == nil {
+		if pool.rl == nil {
+			pool.rl = make(map[limitKey]*rateLimiter)
+		}
+		rl = new(rateLimiter)
+		pool.rl[c.limitKey()] = rl
+	}
+	c.rl = rl
+	wrapTransport(c.Client, func(t http.RoundTripper) http.RoundTripper {
+		return &rateLimitTransport{t, rl}
+	})
+	if p.DebugDump {
+		wrapTransport(c.Client, func(t http.RoundTripper) http.RoundTripper {
+			return &dumpTransport{t}
+		})
+	}
+	if pool.m == nil {
+		pool.m = make(map[clientKey]*sharedClient)
+	}
+	pool.m[k] = c
+	return c
+}
+
+// releaseClient drops c from the pool once no Provider uses it anymore, and
+// its rate limiter once no client of the pool uses that.
+func releaseClient(c *sharedClient) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if c.n--; c.n > 0 || pool.m[c.key] != c {
+		return
+	}
+	delete(pool.m, c.key)
+	for _, o := range pool.m {
+		if o.rl == c.rl {
+			return
+		}
+	}
+	if pool.rl[c.limitKey()] == c.rl {
+		delete(pool.rl, c.limitKey())
+	}
+}
+
+// limitKey returns the account of c, by the URL the API is reached at.
+func (c *sharedClient) limitKey() limitKey {
+	return limitKey{c.key.token, c.BaseURL}
+}
+
+func (c *sharedClient) cachedZone(zone string) *dynv6.Zone {
+	c.zm.RLock()
+	defer c.zm.RUnlock()
+	return c.zc[zone]
+}
+
+func (c *sharedClient) cacheZone(zone string, z *dynv6.Zone) {
+	c.zm.Lock()
+	defer c.zm.Unlock()
+	if z == nil {
+		delete(c.zc, zone)
+		return
+	}
+	if c.zc == nil {
+		c.zc = make(map[string]*dynv6.Zone)
+	}
+	c.zc[zone] = z
+}
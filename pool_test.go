@@ -0,0 +1,41 @@
+package libdynv6
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPoolRateLimiter(t *testing.T) {
+	a := &Provider{BaseURL: `http://a.invalid/api/v2`}
+	b := &Provider{BaseURL: `http://b.invalid/api/v2`}
+	a2 := &Provider{BaseURL: a.BaseURL, HTTPClient: &http.Client{}}
+
+	ca, cb, ca2 := a.sharedClient(`pool-token`), b.sharedClient(`pool-token`), a2.sharedClient(`pool-token`)
+	if ca == ca2 {
+		t.Fatal(`clients with different HTTP clients are shared`)
+	}
+	if ca.rl != ca2.rl {
+		t.Error(`clients of the same account have different rate limiters`)
+	}
+	if ca.rl == cb.rl {
+		t.Error(`clients of the same token at different URLs share a rate limiter`)
+	}
+
+	releaseClient(ca)
+	pool.mu.Lock()
+	kept := pool.rl[ca.limitKey()] == ca.rl
+	pool.mu.Unlock()
+	if !kept {
+		t.Error(`rate limiter dropped while a client uses it`)
+	}
+
+	releaseClient(ca2)
+	releaseClient(cb)
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	for _, k := range []limitKey{ca.limitKey(), cb.limitKey()} {
+		if pool.rl[k] != nil {
+			t.Errorf(`rate limiter of %s kept after the last release`, k.baseURL)
+		}
+	}
+}
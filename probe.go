@@ -0,0 +1,74 @@
+package libdynv6
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// NameserverProbe is the result of probing one nameserver.
+type NameserverProbe struct {
+	Server string        `json:"server"`
+	OK     bool          `json:"ok"`               // answered authoritatively with the zone's SOA
+	RTT    time.Duration `json:"rtt,omitempty"`    // round-trip time of the query
+	Serial uint32        `json:"serial,omitempty"` // SOA serial, differing serials indicate pending propagation
+	Error  string        `json:"error,omitempty"`
+}
+
+// ProbeNameservers queries the SOA record of zone from each authoritative
+// nameserver (see [Propagation.Nameservers]) in parallel and reports
+// reachability, response time and serial.
+func (p *Provider) ProbeNameservers(ctx context.Context, zone string) []NameserverProbe {
+	ns := p.Propagation.Nameservers
+	if len(ns) == 0 {
+		ns = Nameservers
+	}
+	o := make([]NameserverProbe, len(ns))
+
+	var wg sync.WaitGroup
+	for i := range ns {
+		wg.Add(1)
+		go func(r *NameserverProbe, s string) {
+			defer wg.Done()
+			*r = probeNameserver(ctx, s, zone)
+		}(&o[i], ns[i])
+	}
+	wg.Wait()
+	return o
+}
+
+func probeNameserver(ctx context.Context, server, zone string) NameserverProbe {
+	o := NameserverProbe{Server: server}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, `53`)
+	}
+
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(zoneName(zone)), dns.TypeSOA)
+	m.RecursionDesired = false
+	c := dns.Client{Timeout: 5 * time.Second}
+	r, rtt, err := c.ExchangeContext(ctx, m, server)
+	o.RTT = rtt
+	switch {
+	case err != nil:
+		o.Error = err.Error()
+	case r.Rcode != dns.RcodeSuccess:
+		o.Error = dns.RcodeToString[r.Rcode]
+	case !r.Authoritative:
+		o.Error = `not authoritative`
+	default:
+		for _, a := range r.Answer {
+			if soa, ok := a.(*dns.SOA); ok {
+				o.OK, o.Serial = true, soa.Serial
+			}
+		}
+		if !o.OK {
+			o.Error = fmt.Sprintf(`no SOA record for %s`, zoneName(zone))
+		}
+	}
+	return o
+}
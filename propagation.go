@@ -0,0 +1,167 @@
+package libdynv6
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/ZxwyProject/dynv6"
+	"github.com/libdns/libdns"
+	"github.com/miekg/dns"
+)
+
+// Nameservers are the authoritative nameservers of dynv6 zones.
+var Nameservers = []string{`ns1.dynv6.com`, `ns2.dynv6.com`}
+
+// ErrNotPropagated is returned by [Provider.WaitForRecord] if the record
+// did not become visible in time.
+var ErrNotPropagated = errors.New(`record not propagated`)
+
+// Propagation defaults.
+const (
+	DefaultPropagationInterval = 2 * time.Second
+	DefaultPropagationTimeout  = 2 * time.Minute
+)
+
+// Propagation tunes [Provider.WaitForRecord]. The zero value polls all
+// [Nameservers] every DefaultPropagationInterval for up to
+// DefaultPropagationTimeout.
+type Propagation struct {
+	Interval time.Duration `json:"interval,omitempty"` // between polls
+	Timeout  time.Duration `json:"timeout,omitempty"`  // overall, -1 for none besides ctx
+
+	// Authoritative nameservers to poll, as host or host:port.
+	// Defaults to Nameservers.
+	Nameservers []string `json:"nameservers,omitempty"`
+	// Recursive resolvers to poll in addition, e.g. "1.1.1.1".
+	Resolvers []string `json:"resolvers,omitempty"`
+
+	// Number of servers which must answer with the record.
+	// Zero or more than the number of servers means all of them.
+	Consensus int `json:"consensus,omitempty"`
+}
+
+// WaitForRecord polls the nameservers and resolvers configured in
+// [Provider.Propagation], plus resolvers if any (as host or host:port),
+// until enough of them answer with record, or the timeout or ctx expires.
+// Use it before triggering e.g. a DNS-01 validation.
+func (p *Provider) WaitForRecord(ctx context.Context, zone string, record libdns.Record, resolvers ...string) error {
+	c := p.Propagation
+	if c.Interval <= 0 {
+		c.Interval = DefaultPropagationInterval
+	}
+	if c.Timeout == 0 {
+		c.Timeout = DefaultPropagationTimeout
+	}
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	if len(c.Nameservers) == 0 {
+		c.Nameservers = Nameservers
+	}
+	c.Resolvers = append(c.Resolvers[:len(c.Resolvers):len(c.Resolvers)], resolvers...)
+	need := len(c.Nameservers) + len(c.Resolvers)
+	if c.Consensus > 0 && c.Consensus < need {
+		need = c.Consensus
+	}
+
+	rr := record.RR()
+	t := time.NewTicker(c.Interval)
+	defer t.Stop()
+
+	for {
+		n, last := recordVisible(ctx, zone, &rr, &c)
+		if n >= need {
+			return nil
+		}
+		if dynv6.Debug {
+			dynv6.DbgLog.Printf("[Dynv6-debug/libdns] WaitForRecord: %d/%d servers, %v\n", n, need, last)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf(`%w: %s %s seen by %d/%d servers: %v`, ErrNotPropagated, libdns.AbsoluteName(rr.Name, zone), rr.Type, n, need, last)
+		case <-t.C:
+		}
+	}
+}
+
+// recordVisible returns the number of servers answering with rr, and the
+// last error of the others.
+func recordVisible(ctx context.Context, zone string, rr *libdns.RR, c *Propagation) (n int, last error) {
+	for _, s := range c.Nameservers {
+		if err := lookupRecord(ctx, s, false, zone, rr); err != nil {
+			last = err
+		} else {
+			n++
+		}
+	}
+	for _, s := range c.Resolvers {
+		if err := lookupRecord(ctx, s, true, zone, rr); err != nil {
+			last = err
+		} else {
+			n++
+		}
+	}
+	return n, last
+}
+
+// lookupRecord queries server for the RRset of rr and checks that it contains rr.
+func lookupRecord(ctx context.Context, server string, recursive bool, zone string, rr *libdns.RR) error {
+	t, ok := dns.StringToType[rr.Type]
+	if !ok {
+		return fmt.Errorf(`%w: %s`, ErrUnsupportedType, rr.Type)
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, `53`)
+	}
+	origin := dns.Fqdn(zoneName(zone))
+
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(libdns.AbsoluteName(rr.Name, origin)), t)
+	m.RecursionDesired = recursive
+	c := dns.Client{Timeout: 5 * time.Second}
+	r, _, err := c.ExchangeContext(ctx, m, server)
+	if err != nil {
+		return fmt.Errorf(`%s: %v`, server, err)
+	}
+	if r.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf(`%s: %s`, server, dns.RcodeToString[r.Rcode])
+	}
+
+	want := rrKey(rr, origin)
+	for _, a := range r.Answer {
+		if a.Header().Rrtype != t {
+			continue
+		}
+		got := rrFromDNS(a, origin)
+		if rrKey(&got, origin) == want {
+			return nil
+		}
+	}
+	return fmt.Errorf(`%s: %s %s %q not found`, server, rr.Name, rr.Type, rr.Data)
+}
+
+// rrKey normalizes the data of rr for comparison with DNS answers.
+func rrKey(rr *libdns.RR, origin string) string {
+	d := strings.TrimSpace(rr.Data)
+	switch rr.Type {
+	case dynv6.RT_A, dynv6.RT_AAAA:
+		if ip, err := netip.ParseAddr(d); err == nil {
+			d = ip.String()
+		}
+	case dynv6.RT_CNAME, rtNS, dynv6.RT_MX, dynv6.RT_SRV:
+		// the target is the last field
+		f := strings.Fields(d)
+		if n := len(f); n > 0 {
+			f[n-1] = strings.ToLower(dns.Fqdn(libdns.AbsoluteName(f[n-1], origin)))
+		}
+		d = strings.Join(f, ` `)
+	}
+	return rr.Type + ` ` + d
+}
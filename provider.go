@@ -1,52 +1,264 @@
-// Package libdnstemplate implements a DNS record management client compatible
+// Package libdynv6 implements a DNS record management client compatible
 // with the libdns interfaces for Dynv6 REST API.
 package libdynv6
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
 	"sync"
+	"time"
 
 	"github.com/ZxwyProject/dynv6"
 	"github.com/libdns/libdns"
 )
 
-// TODO: Providers must not require additional provisioning steps by the callers; it
-// should work simply by populating a struct and calling methods on it. If your DNS
-// service requires long-lived state or some extra provisioning step, do it implicitly
-// when methods are called; sync.Once can help with this, and/or you can use a
-// sync.(RW)Mutex in your Provider struct to synchronize implicit provisioning.
+// ErrNoToken is returned by all methods of a [Provider] without a token.
+var ErrNoToken = errors.New(`no token provided`)
 
 // Provider facilitates DNS record manipulation with Dynv6 REST API.
+//
+// Providers are encoded to JSON with their tokens redacted, see
+// [Provider.MarshalJSON].
+//
+// A Provider is safe for concurrent use by multiple goroutines, e.g. when
+// shared by Caddy. Its exported fields must not be modified after the
+// first method call. Providers of a process with the same token and client
+// configuration share one dynv6 client, its rate-limit state and zone cache.
 type Provider struct {
-	o sync.Once // for init
+	o   retryOnce    // for init
+	mu  sync.RWMutex // for err, Dynv6, account, clients, accounts and the fields of Close
+	err error        // of init
+
+	sem    semaphore     // for MaxConcurrentRequests
+	quit   chan struct{} // closed by Close
+	closed bool
+	calls  int           // API calls in flight
+	idle   chan struct{} // closed when calls drops to 0 after Close
+
+	am  sync.Mutex // for audit and dry
+	dry []AuditEntry
+	om  sync.Mutex      // for own
+	own map[string]bool // zone/ID of records created by p
+	st  stats
+	zl  zoneLocks
+
+	cm  sync.Mutex            // for chl
+	chl map[string]*challenge // challenge records by zone/name/value
+
+	account  *sharedClient            // for Token
+	clients  map[string]*sharedClient // by zone name, for ZoneTokens
+	accounts []*sharedClient          // one per distinct token
 
 	Dynv6 *dynv6.Client `json:"-"` // internal client
 
 	//# HTTP Token
 	//
 	// You can get it at https://dynv6.com/keys
+	//
+	// Placeholders like {env.DYNV6_TOKEN} or ${DYNV6_TOKEN} are expanded
+	// on the first call, see [ExpandEnv]. The same applies to TokenFile,
+	// ZoneTokens, BaseURL and LockDir.
 	Token string `json:"token,omitempty"`
 
-	// TODO: Put config fields here (with snake_case json struct tags on exported fields), for example:
-	// Exported config fields should be JSON-serializable or omitted (`json:"-"`)
+	//# Token from environment or file
+	//
+	// Name of an environment variable, or path of a file, holding the
+	// token instead of Token. Read on the first call, and again on the
+	// next call if that fails. Only one of the three may be set.
+	TokenEnv  string `json:"token_env,omitempty"`
+	TokenFile string `json:"token_file,omitempty"`
+
+	//# Per-zone HTTP tokens
+	//
+	// Zones which live under other dynv6 accounts, mapped to the token of
+	// that account. Zones not listed here use Token.
+	ZoneTokens map[string]string `json:"zone_tokens,omitempty"`
+
+	//# API base URL
+	//
+	// Overrides the dynv6 REST API endpoint, e.g. to point the provider at
+	// a [dynv6test.Server]. Leave empty for the real API.
+	BaseURL string `json:"base_url,omitempty"`
+
+	// HTTP client for API requests, defaults to the one of [dynv6.NewClient].
+	// It is copied, never modified.
+	HTTPClient *http.Client `json:"-"`
+
+	//# Audit log
+	//
+	// Every record mutation (create, update, delete) is written to Audit
+	// as one JSON-encoded [AuditEntry] per line and passed to AuditFunc.
+	// Both are optional and called serially.
+	Audit     io.Writer        `json:"-"`
+	AuditFunc func(AuditEntry) `json:"-"`
+
+	//# HTTP debug dump
+	//
+	// Dump full request and response bodies to [dynv6.DbgLog].
+	// Authorization and cookie headers are redacted.
+	DebugDump bool `json:"debug_dump,omitempty"`
+
+	//# Dry run
+	//
+	// Do not mutate anything. Mutating methods only collect the operations
+	// they would perform, see [Provider.DryRunOps], and return synthesized
+	// results. Reads still go to the API.
+	DryRun bool `json:"dry_run,omitempty"`
+
+	//# Propagation check
+	//
+	// Polling interval, timeout, nameservers, resolvers and consensus of
+	// [Provider.WaitForRecord].
+	Propagation Propagation `json:"propagation,omitempty"`
+
+	//# Record TTL
+	//
+	// TTL reported for records read from dynv6, whose API does not expose
+	// TTLs. Defaults to DefaultTTL. Use [Provider.ServedTTL] to look up
+	// the TTL actually served by the nameservers.
+	TTL time.Duration `json:"ttl,omitempty"`
+
+	//# Delete own records only
+	//
+	// DeleteRecords only deletes records created by this Provider instance,
+	// so e.g. an ACME client never removes a TXT record at _acme-challenge
+	// belonging to another client sharing the zone.
+	OwnRecordsOnly bool `json:"own_records_only,omitempty"`
+
+	//# Owner ID
+	//
+	// Opt-in TXT registry as used by external-dns: RRsets written by the
+	// Provider get a TXT marker naming this owner once their records are
+	// written, and RRsets without one, e.g. created by hand, or with another
+	// owner are never changed (ErrNotOwned) or deleted, by any method.
+	// SyncZone, ReplaceAllRecords and PurgeZone leave them and all markers
+	// alone. Markers are at _owner-<type>.<name>, e.g. _owner-a.www, and
+	// hold "heritage=libdynv6,libdynv6/owner=<OwnerID>".
+	OwnerID string `json:"owner_id,omitempty"`
+
+	//# Maximum concurrent requests
+	//
+	// Limits the API calls in flight across all goroutines using the
+	// Provider; further calls wait. Unlimited if 0. See also [WithConcurrency].
+	MaxConcurrentRequests int `json:"max_concurrent_requests,omitempty"`
+
+	//# Lock directory
+	//
+	// Mutations of a zone are serialized within a Provider. If set, they
+	// also take an advisory lock on a file per zone in this directory,
+	// serializing them across all processes sharing it.
+	LockDir string `json:"lock_dir,omitempty"`
+
+	// Record conversion between dynv6 and libdns, defaults to
+	// [DefaultConverter].
+	Converter RecordConverter `json:"-"`
 }
 
-func (p *Provider) init() {
+// init sets up the clients. If it fails, the error is returned by all API
+// calls until the next call of a method runs init again.
+func (p *Provider) init() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.quit == nil {
+		p.quit = make(chan struct{})
+	}
+	if p.closed {
+		p.err = ErrClosed
+		return p.err
+	}
+
 	// You must ensure that the token is filled in before the first call!
-	if p.Token == `` {
-		panic(`libdynv6: No token provided!`)
+	tok, err := p.ResolveToken()
+	if err != nil {
+		p.err = err
+		return err
+	}
+	if tok == `` && len(p.ZoneTokens) == 0 {
+		p.err = ErrNoToken
+		return p.err
+	}
+	p.err = nil
+	if p.MaxConcurrentRequests > 0 && p.sem == nil {
+		p.sem = make(semaphore, p.MaxConcurrentRequests)
+	}
+	if tok != `` {
+		p.account = p.sharedClient(tok)
+		p.Dynv6 = p.account.Client
+		p.accounts = append(p.accounts, p.account)
+	}
+
+	t := make(map[string]*sharedClient, len(p.ZoneTokens))
+	p.clients = make(map[string]*sharedClient, len(p.ZoneTokens))
+	for zone, token := range p.ZoneTokens {
+		token = ExpandEnv(token)
+		c := t[token]
+		if c == nil {
+			if token == tok {
+				c = p.account
+			} else {
+				c = p.sharedClient(token)
+				p.accounts = append(p.accounts, c)
+			}
+			t[token] = c
+		}
+		p.clients[zoneName(zone)] = c
+	}
+	return nil
+}
+
+// client returns the client responsible for zone and begins an API call,
+// see p.begin. The caller must end it with the returned function.
+func (p *Provider) client(ctx context.Context, zone string) (*sharedClient, func(), error) {
+	c, err := p.lookup(zone)
+	if err != nil {
+		return nil, nil, err
+	}
+	done, err := p.begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, done, nil
+}
+
+// lookup returns the client responsible for zone.
+func (p *Provider) lookup(zone string) (*sharedClient, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.lookupLocked(zone)
+}
+
+// lookupLocked is lookup with p.mu held.
+func (p *Provider) lookupLocked(zone string) (*sharedClient, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	if c := p.clients[zoneName(zone)]; c != nil {
+		return c, nil
 	}
-	p.Dynv6 = dynv6.NewClient(p.Token)
+	if p.account == nil {
+		return nil, fmt.Errorf(`libdynv6: no token for zone %s`, zone)
+	}
+	return p.account, nil
+}
+
+// wrapTransport replaces the transport of d with f(transport).
+// The *http.Client is copied, so a shared client is never modified.
+func wrapTransport(d *dynv6.Client, f func(http.RoundTripper) http.RoundTripper) {
+	c := http.Client{}
+	if d.HTTPClient != nil {
+		c = *d.HTTPClient
+	}
+	c.Transport = f(transport(&c))
+	d.HTTPClient = &c
 }
 
 // GetRecords returns all the records in the DNS zone.
 func (p *Provider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
 	p.o.Do(p.init)
-	z, err := p.Dynv6.ZoneNameCtx(ctx, zone)
-	if err != nil {
-		return nil, err
-	}
-	r, err := p.Dynv6.RecordsCtx(ctx, string(z.ID))
+	_, r, err := p.records(ctx, zone)
 	if err != nil {
 		return nil, err
 	}
@@ -54,136 +266,202 @@ func (p *Provider) GetRecords(ctx context.Context, zone string) ([]libdns.Record
 	o := make([]libdns.Record, l)
 
 	for i := 0; i < l; i++ {
-		o[i] = recordToLibdns(&r[i])
+		o[i] = p.libdnsRecord(&r[i])
 	}
 	// Make sure to return RR-type-specific structs, not libdns.RR structs.
 	return o, nil
 }
 
 // AppendRecords creates the inputted records in the given zone and returns the populated records that were created.
-// It never changes existing records.
+// It never changes existing records. Records which already exist with exactly the same content are skipped,
+// also if created by a concurrent call: mutations of a zone are serialized, see [Provider.LockDir].
 func (p *Provider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
 	p.o.Do(p.init)
-	z, err := p.Dynv6.ZoneNameCtx(ctx, zone)
+	unlock, err := p.lockZone(ctx, zone)
 	if err != nil {
 		return nil, err
 	}
-	r, err := p.Dynv6.RecordsCtx(ctx, string(z.ID))
+	defer unlock()
+	z, r, err := p.records(ctx, zone)
 	if err != nil {
 		return nil, err
 	}
-	l, m, n := len(records), len(r), 0
-	o := make([]libdns.Record, l)
+	return p.appendRecords(ctx, zone, z, r, records)
+}
 
+func (p *Provider) appendRecords(ctx context.Context, zone string, z *dynv6.Zone, r []dynv6.Record, records []libdns.Record) ([]libdns.Record, error) {
+	l, m := len(records), len(r)
+	o := make([]libdns.Record, 0, l)
+	c := make([]Change, l)
 	for i := 0; i < l; i++ {
-		li := records[i]
-		lr := li.RR()
+		c[i] = Change{Type: ChangeCreate, Record: records[i].RR()}
+	}
+
+	_, err := p.guarded(ctx, zone, z, r, c, func() ([]Change, error) {
+		added := make([]Change, 0, l)
+		for i := 0; i < l; i++ {
+			lr := c[i].Record
 
-		if recordFind(r, &lr, m) != nil {
-			if dynv6.Debug {
-				dynv6.DbgLog.Println(`[Dynv6-debug/libdns] AppendRecords:`, libdns.AbsoluteName(lr.Name, zone), `already exists!`)
+			dr, err := p.converter().FromLibdns(&lr)
+			if err != nil {
+				return added, err
 			}
-			continue
-		}
 
-		dr, err := recordFromLibdns(&lr)
-		if err != nil {
-			return nil, err
-		}
+			if fr, _ := recordFind(p.converter(), zone, r, &lr, m, nil); fr != nil {
+				if dynv6.Debug {
+					dynv6.DbgLog.Println(`[Dynv6-debug/libdns] AppendRecords:`, libdns.AbsoluteName(lr.Name, zone), lr.Type, lr.Data, `already exists!`)
+				}
+				continue
+			}
 
-		_, err = p.Dynv6.RecordAddCtx(ctx, string(z.ID), dr)
-		if err != nil {
-			return nil, err
+			if _, err = p.recordAdd(ctx, zone, z, &lr, dr); err != nil {
+				return added, err
+			}
+			added = append(added, c[i])
+			o = append(o, lr)
 		}
-		o[n] = lr
-		n++
+		return added, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	// Make sure to return RR-type-specific structs, not libdns.RR structs.
-	return o[:n], nil
+	return o, nil
 }
 
 // SetRecords updates the zone so that the records described in the input are reflected in the output.
 // It may create or update records or—depending on the record type—delete records to maintain parity with the input.
 // No other records are affected. It returns the records which were set.
+// See [Provider.Plan] for the changes it performs.
 func (p *Provider) SetRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	return p.setRecords(ctx, zone, records, time.Time{})
+}
+
+func (p *Provider) setRecords(ctx context.Context, zone string, records []libdns.Record, since time.Time) ([]libdns.Record, error) {
 	p.o.Do(p.init)
-	z, err := p.Dynv6.ZoneNameCtx(ctx, zone)
+	unlock, err := p.lockZone(ctx, zone)
 	if err != nil {
 		return nil, err
 	}
-	r, err := p.Dynv6.RecordsCtx(ctx, string(z.ID))
+	defer unlock()
+	z, r, err := p.records(ctx, zone)
 	if err != nil {
 		return nil, err
 	}
-	l, m := len(records), len(r)
+	if !since.IsZero() && z.UpdatedAt.After(since) {
+		return nil, ErrConflict
+	}
+	return p.setRecordsIn(ctx, zone, z, r, records)
+}
+
+func (p *Provider) setRecordsIn(ctx context.Context, zone string, z *dynv6.Zone, r []dynv6.Record, records []libdns.Record) ([]libdns.Record, error) {
+	pl, err := planRRsets(p.converter(), zone, r, records)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = p.applyPlan(ctx, z, r, pl); err != nil {
+		return nil, err
+	}
+	l := len(records)
 	o := make([]libdns.Record, l)
 
 	for i := 0; i < l; i++ {
-		li := records[i]
-		lr := li.RR()
-
-		dr, err := recordFromLibdns(&lr)
-		if err != nil {
-			return nil, err
-		}
-
-		fr := recordFind(r, &lr, m)
-		if fr == nil {
-			// new
-			_, err = p.Dynv6.RecordAddCtx(ctx, string(z.ID), dr)
-		} else {
-			// upd
-			_, err = p.Dynv6.RecordUpdCtx(ctx, string(z.ID), string(fr.ID), dr)
-		}
-		if err != nil {
-			return nil, err
-		}
-		o[i] = lr
+		o[i] = records[i].RR()
 	}
 	// Make sure to return RR-type-specific structs, not libdns.RR structs.
 	return o, nil
 }
 
 // DeleteRecords deletes the given records from the zone if they exist in the zone and exactly match the input.
+// An empty Type or Data in the input matches any value, TTL is ignored.
+// Records returned by GetRecords are deleted by their dynv6 ID, see [Record].
+// With OwnRecordsOnly, records not created by p are never deleted.
 // If the input records do not exist in the zone, they are silently ignored.
 // DeleteRecords returns only the the records that were deleted, and does not return any records that were provided in the input but did not exist in the zone.
 func (p *Provider) DeleteRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
 	p.o.Do(p.init)
-	z, err := p.Dynv6.ZoneNameCtx(ctx, zone)
+	unlock, err := p.lockZone(ctx, zone)
 	if err != nil {
 		return nil, err
 	}
-	r, err := p.Dynv6.RecordsCtx(ctx, string(z.ID))
+	defer unlock()
+	z, r, err := p.records(ctx, zone)
 	if err != nil {
 		return nil, err
 	}
-	l, m, n := len(records), len(r), 0
-	o := make([]libdns.Record, l)
+	return p.deleteRecords(ctx, zone, z, r, records)
+}
+
+func (p *Provider) deleteRecords(ctx context.Context, zone string, z *dynv6.Zone, r []dynv6.Record, records []libdns.Record) ([]libdns.Record, error) {
+	var err error
+	l, m := len(records), len(r)
+	o := make([]libdns.Record, 0, l)
+	used := make([]bool, m)
+	if p.OwnRecordsOnly {
+		for i := range r {
+			used[i] = !p.owned(zone, string(r[i].ID))
+		}
+	}
+	if p.OwnerID != `` {
+		for i, x := range p.notOwned(r) {
+			used[i] = used[i] || x
+		}
+	}
 
 	for i := 0; i < l; i++ {
 		li := records[i]
 		lr := li.RR()
 
-		fr := recordFind(r, &lr, m)
-		if fr == nil {
+		if id := RecordID(li); id != `` {
+			// by ID, as returned by GetRecords
+			for j := range r {
+				if !used[j] && string(r[j].ID) == id {
+					used[j] = true
+					if err = p.recordDel(ctx, zone, z, id, &lr); err != nil {
+						return nil, err
+					}
+					p.disown(zone, id)
+					o = append(o, p.libdnsRecord(&r[j]))
+					break
+				}
+			}
 			continue
 		}
 
-		err = p.Dynv6.RecordDelCtx(ctx, string(z.ID), string(fr.ID))
-		if err != nil {
-			return nil, err
+		for {
+			fr, err := recordFind(p.converter(), zone, r, &lr, m, used)
+			if err != nil {
+				return nil, err
+			}
+			if fr == nil {
+				break
+			}
+
+			dl := p.libdnsRecord(fr)
+			dr := dl.RR()
+			err = p.recordDel(ctx, zone, z, string(fr.ID), &dr)
+			if err != nil {
+				return nil, err
+			}
+			p.disown(zone, string(fr.ID))
+			o = append(o, dl)
+			if lr.Type != `` && lr.Data != `` {
+				break
+			}
 		}
-		o[n] = lr
-		n++
 	}
-	// Make sure to return RR-type-specific structs, not libdns.RR structs.
-	return o[:n], nil
+	if p.OwnerID != `` {
+		if err = p.settle(ctx, zone, z, r, deleted(o)); err != nil {
+			return o, err
+		}
+	}
+	return o, nil
 }
 
 // ListZones returns the list of available DNS zones for use by other [libdns] methods.
 func (p *Provider) ListZones(ctx context.Context) ([]libdns.Zone, error) {
 	p.o.Do(p.init)
-	z, err := p.Dynv6.ZonesCtx(ctx)
+	z, err := p.zones(ctx)
 	if err != nil {
 		return nil, err
 	}
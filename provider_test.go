@@ -0,0 +1,62 @@
+package libdynv6_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/ZxwyProject/libdynv6"
+	"github.com/ZxwyProject/libdynv6/dynv6test"
+)
+
+// concurrently calls f from n goroutines at once and returns their errors.
+func concurrently(n int, f func() error) []error {
+	errs := make([]error, n)
+	var start, wg sync.WaitGroup
+	start.Add(1)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			start.Wait()
+			errs[i] = f()
+		}(i)
+	}
+	start.Done()
+	wg.Wait()
+	return errs
+}
+
+func TestConcurrentFirstCall(t *testing.T) {
+	const zone = `example.dynv6.net`
+	m := dynv6test.NewMemory(zone)
+	m.AddRecord(zone, dynv6test.Record{Type: `A`, Name: `www`, Data: `192.0.2.1`})
+	ctx := context.Background()
+
+	for _, err := range concurrently(16, func() error {
+		r, err := m.GetRecords(ctx, zone)
+		if err == nil && len(r) != 1 {
+			err = errors.New(`wrong records`)
+		}
+		return err
+	}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestConcurrentNoToken(t *testing.T) {
+	p := new(libdynv6.Provider)
+	ctx := context.Background()
+
+	for _, err := range concurrently(16, func() error {
+		_, err := p.GetRecords(ctx, `example.dynv6.net`)
+		return err
+	}) {
+		if !errors.Is(err, libdynv6.ErrNoToken) {
+			t.Fatalf(`%v, want ErrNoToken`, err)
+		}
+	}
+}
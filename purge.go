@@ -0,0 +1,111 @@
+package libdynv6
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// ErrNotConfirmed is returned by [Provider.PurgeZone] if the confirmation is missing.
+var ErrNotConfirmed = errors.New(`purge not confirmed`)
+
+// PurgeOptions controls which records [Provider.PurgeZone] deletes.
+type PurgeOptions struct {
+	// Only delete records of these types. All types if empty.
+	Types []string `json:"types,omitempty"`
+	// Only delete records whose name starts with NamePrefix.
+	NamePrefix string `json:"name_prefix,omitempty"`
+
+	// Only return the records which would be deleted.
+	DryRun bool `json:"dry_run,omitempty"`
+	// Must be the zone name, unless DryRun is set.
+	Confirm string `json:"confirm,omitempty"`
+}
+
+func (o *PurgeOptions) match(typ, name string) bool {
+	if o.NamePrefix != `` && !strings.HasPrefix(name, o.NamePrefix) {
+		return false
+	}
+	if len(o.Types) == 0 {
+		return true
+	}
+	for _, t := range o.Types {
+		if strings.EqualFold(t, typ) {
+			return true
+		}
+	}
+	return false
+}
+
+// PurgeZone deletes all records of the zone matching opts and returns them.
+//
+// Unless opts.DryRun is set, opts.Confirm must equal the zone name, otherwise
+// [ErrNotConfirmed] is returned and nothing is deleted. If an error occurs,
+// the records deleted so far are returned along with it. With an OwnerID,
+// only records of owned RRsets are deleted, see [Provider.OwnerID].
+func (p *Provider) PurgeZone(ctx context.Context, zone string, opts PurgeOptions) ([]libdns.Record, error) {
+	if !opts.DryRun && zoneName(opts.Confirm) != zoneName(zone) {
+		return nil, ErrNotConfirmed
+	}
+
+	p.o.Do(p.init)
+	unlock, err := p.lockZone(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	z, r, err := p.records(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	o := make([]libdns.Record, 0, len(r))
+	var skip []bool
+	if p.OwnerID != `` {
+		skip = p.notOwned(r)
+	}
+
+	for i := range r {
+		if !opts.match(r[i].Type, r[i].Name) || (skip != nil && skip[i]) {
+			continue
+		}
+		lr := p.libdnsRecord(&r[i])
+		if !opts.DryRun {
+			rr := lr.RR()
+			if err = p.recordDel(ctx, zone, z, string(r[i].ID), &rr); err != nil {
+				break
+			}
+		}
+		o = append(o, lr)
+	}
+	if p.OwnerID != `` && !opts.DryRun {
+		if e := p.settle(ctx, zone, z, r, deleted(o)); err == nil {
+			err = e
+		}
+	}
+	return o, err
+}
+
+// DeleteOptions controls [Provider.DeleteAllOfType].
+type DeleteOptions struct {
+	// Only delete records whose name starts with NamePrefix, e.g. "_acme-challenge".
+	NamePrefix string `json:"name_prefix,omitempty"`
+	// Only return the records which would be deleted.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// DeleteAllOfType deletes all records of type rType in the zone, e.g. stale
+// TXT challenge records left by failed issuances, and returns them.
+// It is [Provider.PurgeZone] limited to one type, without confirmation.
+func (p *Provider) DeleteAllOfType(ctx context.Context, zone, rType string, opts DeleteOptions) ([]libdns.Record, error) {
+	if rType == `` {
+		return nil, errors.New(`libdynv6: DeleteAllOfType needs a record type`)
+	}
+	return p.PurgeZone(ctx, zone, PurgeOptions{
+		Types:      []string{rType},
+		NamePrefix: opts.NamePrefix,
+		DryRun:     opts.DryRun,
+		Confirm:    zone,
+	})
+}
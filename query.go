@@ -0,0 +1,77 @@
+package libdynv6
+
+import (
+	"context"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/ZxwyProject/dynv6"
+	"github.com/libdns/libdns"
+)
+
+// RecordQuery selects records in [Provider.FindRecords] by structured
+// criteria. All conditions must match; empty conditions match everything.
+//
+// For example, all CNAMEs pointing at old-host:
+//
+//	RecordQuery{Types: []string{`CNAME`}, Data: regexp.MustCompile(`^old-host\.`)}
+type RecordQuery struct {
+	Types []string       // record types
+	Name  string         // relative name pattern, see [path.Match]
+	Data  *regexp.Regexp // record data in libdns notation, e.g. "10 mail.example.com." for MX
+
+	// Priority range, inclusive. If either is set, only MX and SRV records match.
+	MinPriority, MaxPriority *uint16
+}
+
+func (q *RecordQuery) match(r *dynv6.Record, rr *libdns.RR) bool {
+	if len(q.Types) > 0 {
+		ok := false
+		for _, t := range q.Types {
+			ok = ok || strings.EqualFold(t, r.Type)
+		}
+		if !ok {
+			return false
+		}
+	}
+	if q.Name != `` {
+		if ok, _ := path.Match(q.Name, r.Name); !ok {
+			return false
+		}
+	}
+	if q.Data != nil && !q.Data.MatchString(rr.Data) {
+		return false
+	}
+	if q.MinPriority != nil || q.MaxPriority != nil {
+		if r.Type != dynv6.RT_MX && r.Type != dynv6.RT_SRV {
+			return false
+		}
+		if q.MinPriority != nil && r.Priority < *q.MinPriority {
+			return false
+		}
+		if q.MaxPriority != nil && r.Priority > *q.MaxPriority {
+			return false
+		}
+	}
+	return true
+}
+
+// FindRecords returns the records in the zone matching q.
+func (p *Provider) FindRecords(ctx context.Context, zone string, q RecordQuery) ([]libdns.Record, error) {
+	p.o.Do(p.init)
+	_, r, err := p.records(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	var o []libdns.Record
+	for i := range r {
+		x := p.libdnsRecord(&r[i])
+		rr := x.RR()
+		if q.match(&r[i], &rr) {
+			o = append(o, x)
+		}
+	}
+	// Make sure to return RR-type-specific structs, not libdns.RR structs.
+	return o, nil
+}
@@ -0,0 +1,181 @@
+package libdynv6
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit is the rate-limit budget last reported by the dynv6 API.
+type RateLimit struct {
+	Limit      int           `json:"limit"`       // requests per window, -1 if unknown
+	Remaining  int           `json:"remaining"`   // requests left in the window, -1 if unknown
+	Reset      time.Time     `json:"reset"`       // start of the next window, zero if unknown
+	RetryAfter time.Duration `json:"retry_after"` // from a Retry-After header, if any
+	Updated    time.Time     `json:"updated"`     // time of the response
+}
+
+func (r *RateLimit) String() string {
+	s := fmt.Sprintf(`%d/%d remaining`, r.Remaining, r.Limit)
+	if !r.Reset.IsZero() {
+		s += `, reset at ` + r.Reset.Format(time.RFC3339)
+	}
+	if r.RetryAfter > 0 {
+		s += `, retry after ` + r.RetryAfter.String()
+	}
+	return s
+}
+
+// RateLimitError wraps an API error with the rate-limit budget known at that time.
+type RateLimitError struct {
+	Err       error
+	RateLimit RateLimit
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf(`%v (rate limit: %s)`, e.Err, &e.RateLimit)
+}
+
+func (e *RateLimitError) Unwrap() error { return e.Err }
+
+// rateLimiter tracks the budget of one token and throttles its requests.
+type rateLimiter struct {
+	mu sync.Mutex
+	r  RateLimit
+	ok bool
+}
+
+// wait blocks while the known budget is exhausted or a Retry-After is
+// pending, or until ctx is done, and then takes one request from the budget.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		d := l.delay(time.Now())
+		if d <= 0 {
+			if l.ok && l.r.Remaining > 0 {
+				l.r.Remaining--
+			}
+			l.mu.Unlock()
+			return nil
+		}
+		l.mu.Unlock()
+
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// delay returns how long to wait before the next request. l.mu must be held.
+func (l *rateLimiter) delay(now time.Time) time.Duration {
+	if !l.ok {
+		return 0
+	}
+	if l.r.RetryAfter > 0 {
+		if d := l.r.Updated.Add(l.r.RetryAfter).Sub(now); d > 0 {
+			return d
+		}
+	}
+	if l.r.Remaining == 0 && !l.r.Reset.IsZero() {
+		return l.r.Reset.Sub(now)
+	}
+	return 0
+}
+
+func (l *rateLimiter) get() (RateLimit, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r, l.ok
+}
+
+func (l *rateLimiter) update(h http.Header, now time.Time) {
+	r := RateLimit{
+		Limit:     headerInt(h, `X-RateLimit-Limit`, `RateLimit-Limit`),
+		Remaining: headerInt(h, `X-RateLimit-Remaining`, `RateLimit-Remaining`),
+		Updated:   now,
+	}
+	if n := headerInt(h, `X-RateLimit-Reset`, `RateLimit-Reset`); n >= 0 {
+		if n > 1e9 {
+			// unix timestamp
+			r.Reset = time.Unix(int64(n), 0)
+		} else {
+			// delta seconds
+			r.Reset = now.Add(time.Duration(n) * time.Second)
+		}
+	}
+	if s := h.Get(`Retry-After`); s != `` {
+		if n, err := strconv.Atoi(s); err == nil {
+			r.RetryAfter = time.Duration(n) * time.Second
+		} else if t, err := http.ParseTime(s); err == nil {
+			r.RetryAfter = t.Sub(now)
+		}
+	}
+	if r.Limit < 0 && r.Remaining < 0 && r.Reset.IsZero() && r.RetryAfter == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	l.r, l.ok = r, true
+	l.mu.Unlock()
+}
+
+func headerInt(h http.Header, keys ...string) int {
+	for _, k := range keys {
+		if n, err := strconv.Atoi(h.Get(k)); err == nil {
+			return n
+		}
+	}
+	return -1
+}
+
+// rateLimitTransport holds back requests while the budget of the token is
+// exhausted and records rate-limit headers of every response. Since dynv6
+// limits per account, the limiter is shared by all clients of a token.
+type rateLimitTransport struct {
+	next http.RoundTripper
+	l    *rateLimiter
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.l.wait(req.Context()); err != nil {
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err == nil {
+		t.l.update(resp.Header, time.Now())
+	}
+	return resp, err
+}
+
+// RateLimitStatus returns the rate-limit budget reported by the most recent
+// API response for any token of p, also to other Providers sharing the token.
+// ok is false if dynv6 has not reported any rate-limit headers yet.
+func (p *Provider) RateLimitStatus() (r RateLimit, ok bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, c := range p.accounts {
+		if x, k := c.rl.get(); k && (!ok || x.Updated.After(r.Updated)) {
+			r, ok = x, true
+		}
+	}
+	return r, ok
+}
+
+// rateLimitErr wraps err with the current rate-limit budget of c, if any.
+func (c *sharedClient) rateLimitErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	r, ok := c.rl.get()
+	if !ok {
+		return err
+	}
+	return &RateLimitError{err, r}
+}
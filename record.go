@@ -0,0 +1,60 @@
+package libdynv6
+
+import (
+	"time"
+
+	"github.com/ZxwyProject/dynv6"
+	"github.com/libdns/libdns"
+)
+
+// Record is a record returned by the [Provider]: a [libdns.RR] together
+// with its dynv6 record ID, so it can be deleted directly and unambiguously.
+type Record struct {
+	Name string        `json:"name"`
+	TTL  time.Duration `json:"ttl"`
+	Type string        `json:"type"`
+	Data string        `json:"data"`
+	ID   string        `json:"id,omitempty"` // dynv6 record ID
+
+	// Data with names and addresses expanded by dynv6, e.g. the full
+	// address of an AAAA record relative to the zone's IPv6 prefix.
+	// Empty if equal to Data.
+	Expanded string `json:"expanded,omitempty"`
+
+	raw *dynv6.Record
+}
+
+// RR returns the record without ID.
+func (r Record) RR() libdns.RR {
+	return libdns.RR{Name: r.Name, TTL: r.TTL, Type: r.Type, Data: r.Data}
+}
+
+// Raw returns the dynv6 record r was converted from, with provider-specific
+// fields like ExpandedData, or nil if r was not returned by the [Provider].
+// It must not be modified.
+func (r Record) Raw() *dynv6.Record { return r.raw }
+
+// RawRecord returns the dynv6 record behind a record returned by the
+// [Provider], see [Record.Raw].
+func RawRecord(r libdns.Record) (*dynv6.Record, bool) {
+	var d *dynv6.Record
+	switch x := r.(type) {
+	case Record:
+		d = x.raw
+	case *Record:
+		d = x.raw
+	}
+	return d, d != nil
+}
+
+// RecordID returns the dynv6 record ID of a record returned by the
+// [Provider], or "" for other records.
+func RecordID(r libdns.Record) string {
+	switch x := r.(type) {
+	case Record:
+		return x.ID
+	case *Record:
+		return x.ID
+	}
+	return ``
+}
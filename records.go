@@ -0,0 +1,71 @@
+package libdynv6
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// ErrRecordNotFound is returned by [Provider.GetRecord] if no record matches,
+// and by [Resources] if a record no longer exists.
+var ErrRecordNotFound = errors.New(`record not found`)
+
+// GetRecordsFiltered returns the records in the zone matching f, see
+// [RecordFilter]. The filter is applied client-side.
+func (p *Provider) GetRecordsFiltered(ctx context.Context, zone string, f RecordFilter) ([]libdns.Record, error) {
+	p.o.Do(p.init)
+	_, r, err := p.records(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	var o []libdns.Record
+	for i := range r {
+		if f.match(r[i].Name, r[i].Type) {
+			o = append(o, p.libdnsRecord(&r[i]))
+		}
+	}
+	// Make sure to return RR-type-specific structs, not libdns.RR structs.
+	return o, nil
+}
+
+// GetRecord returns the records of the RRset name and rType in the zone, or
+// [ErrRecordNotFound]. name is relative to the zone, "" or "@" is the apex.
+func (p *Provider) GetRecord(ctx context.Context, zone, name, rType string) ([]libdns.Record, error) {
+	if name == `@` {
+		name = ``
+	}
+	p.o.Do(p.init)
+	_, r, err := p.records(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	var o []libdns.Record
+	for i := range r {
+		if r[i].Name == name && strings.EqualFold(r[i].Type, rType) {
+			o = append(o, p.libdnsRecord(&r[i]))
+		}
+	}
+	if len(o) == 0 {
+		return nil, ErrRecordNotFound
+	}
+	return o, nil
+}
+
+// DeleteRecordsByName deletes all records at name in the zone, or only those
+// of the given types, and returns the deleted records. name is relative to
+// the zone, "" or "@" is the apex.
+func (p *Provider) DeleteRecordsByName(ctx context.Context, zone, name string, types ...string) ([]libdns.Record, error) {
+	if name == `@` {
+		name = ``
+	}
+	d := []libdns.Record{libdns.RR{Name: name}}
+	if len(types) > 0 {
+		d = d[:0]
+		for _, t := range types {
+			d = append(d, libdns.RR{Name: name, Type: strings.ToUpper(t)})
+		}
+	}
+	return p.DeleteRecords(ctx, zone, d)
+}
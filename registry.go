@@ -0,0 +1,283 @@
+package libdynv6
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ZxwyProject/dynv6"
+	"github.com/libdns/libdns"
+)
+
+// ErrNotOwned is returned for changes of RRsets owned by another owner, or
+// created outside of the registry, see [Provider.OwnerID].
+var ErrNotOwned = errors.New(`RRset not owned`)
+
+// With an OwnerID, ownership is recorded in the zone itself, as external-dns
+// does with its TXT registry: each RRset written by the Provider gets a TXT
+// marker at
+//
+//	_owner-<type>.<name>    e.g. _owner-a.www, or _owner-a for the apex
+//
+// holding "heritage=libdynv6,libdynv6/owner=<OwnerID>". Unlike the records
+// tracked by OwnRecordsOnly, this survives restarts and is shared by all
+// instances using the same OwnerID. Markers are ordinary records, returned
+// by GetRecords, but never changed or deleted by the Provider but through
+// the registry.
+
+// ownerPrefix starts the first label of marker names.
+const ownerPrefix = `_owner-`
+
+type ownedSet struct{ name, typ string }
+
+func ownedSetOf(name, typ string) ownedSet {
+	if name == `@` {
+		name = ``
+	}
+	return ownedSet{name, strings.ToUpper(typ)}
+}
+
+// markerName returns the name of the marker of k.
+func (k ownedSet) markerName() string {
+	n := ownerPrefix + strings.ToLower(k.typ)
+	if k.name != `` {
+		n += `.` + k.name
+	}
+	return n
+}
+
+// markerData returns the data of markers of owner.
+func markerData(owner string) string {
+	return `heritage=libdynv6,libdynv6/owner=` + owner
+}
+
+// registry is the ownership of the RRsets of a zone.
+type registry struct {
+	owner   map[ownedSet]string         // by RRset with a marker
+	markers map[ownedSet][]dynv6.Record // by RRset
+	sets    map[ownedSet]int            // records by RRset, without markers
+}
+
+// parseMarker returns the RRset and owner of r if it is a marker.
+func parseMarker(r *dynv6.Record) (ownedSet, string, bool) {
+	if r.Type != dynv6.RT_TXT || !strings.HasPrefix(r.Name, ownerPrefix) {
+		return ownedSet{}, ``, false
+	}
+	label, name, _ := strings.Cut(r.Name, `.`)
+	var owner string
+	for _, f := range strings.Split(strings.Trim(r.Data, `"`), `,`) {
+		k, v, _ := strings.Cut(f, `=`)
+		switch {
+		case k == `heritage` && v != `libdynv6`:
+			return ownedSet{}, ``, false
+		case k == `libdynv6/owner`:
+			owner = v
+		}
+	}
+	if owner == `` {
+		return ownedSet{}, ``, false
+	}
+	return ownedSetOf(name, strings.TrimPrefix(label, ownerPrefix)), owner, true
+}
+
+func newRegistry(r []dynv6.Record) *registry {
+	g := &registry{
+		owner:   make(map[ownedSet]string),
+		markers: make(map[ownedSet][]dynv6.Record),
+		sets:    make(map[ownedSet]int),
+	}
+	for i := range r {
+		if k, owner, ok := parseMarker(&r[i]); ok {
+			if _, dup := g.owner[k]; !dup {
+				g.owner[k] = owner
+			}
+			g.markers[k] = append(g.markers[k], r[i])
+			continue
+		}
+		g.sets[ownedSetOf(r[i].Name, r[i].Type)]++
+	}
+	return g
+}
+
+// owns reports whether the RRset k is marked as owned by owner.
+func (g *registry) owns(owner string, k ownedSet) bool {
+	o, ok := g.owner[k]
+	return ok && o == owner
+}
+
+// guarded calls apply, which applies changes to zone and returns those it
+// applied. With an OwnerID, the changes may only touch RRsets owned by it or
+// create new ones, otherwise nothing is applied and [ErrNotOwned] returned.
+// Once the records are written, markers are created for the new RRsets and
+// deleted for the emptied ones. r are the records of the zone, read if nil.
+func (p *Provider) guarded(ctx context.Context, zone string, z *dynv6.Zone, r []dynv6.Record, changes []Change, apply func() ([]Change, error)) ([]Change, error) {
+	if p.OwnerID == `` {
+		return apply()
+	}
+	if r == nil {
+		var err error
+		if r, err = p.recordsIn(ctx, zone, z); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.guard(zone, r, changes); err != nil {
+		return nil, err
+	}
+	o, err := apply()
+	if e := p.settle(ctx, zone, z, r, o); err == nil {
+		err = e
+	}
+	return o, err
+}
+
+// guard checks that changes only touch RRsets of r owned by p.OwnerID, or
+// create RRsets which neither have records nor a marker yet.
+func (p *Provider) guard(zone string, r []dynv6.Record, changes []Change) error {
+	g := newRegistry(r)
+	for i := range changes {
+		c := &changes[i]
+		var old ownedSet
+		if c.Type != ChangeCreate {
+			old = changedSet(r, c)
+			if err := g.check(p.OwnerID, zone, old, false); err != nil {
+				return err
+			}
+		}
+		if k := ownedSetOf(c.Record.Name, c.Record.Type); c.Type != ChangeDelete && (c.Type == ChangeCreate || k != old) {
+			if err := g.check(p.OwnerID, zone, k, true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// check returns [ErrNotOwned] unless k is owned by owner, or is a new RRset
+// and create is set. Markers are never owned.
+func (g *registry) check(owner, zone string, k ownedSet, create bool) error {
+	if !strings.HasPrefix(k.name, ownerPrefix) {
+		if g.owns(owner, k) {
+			return nil
+		}
+		if _, ok := g.owner[k]; !ok && create && g.sets[k] == 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf(`%w: %s %s`, ErrNotOwned, libdns.AbsoluteName(k.name, zone), k.typ)
+}
+
+// changedSet returns the RRset of r updated or deleted by c.
+func changedSet(r []dynv6.Record, c *Change) ownedSet {
+	for i := range r {
+		if string(r[i].ID) == c.ID {
+			return ownedSetOf(r[i].Name, r[i].Type)
+		}
+	}
+	if c.Old != nil {
+		return ownedSetOf(c.Old.Name, c.Old.Type)
+	}
+	return ownedSetOf(c.Record.Name, c.Record.Type)
+}
+
+// settle creates the markers of the RRsets of r which applied created, and
+// deletes those of the RRsets it emptied.
+func (p *Provider) settle(ctx context.Context, zone string, z *dynv6.Zone, r []dynv6.Record, applied []Change) error {
+	g := newRegistry(r)
+	var touched []ownedSet
+	seen := make(map[ownedSet]bool)
+	count := func(k ownedSet, n int) {
+		g.sets[k] += n
+		if !seen[k] {
+			seen[k] = true
+			touched = append(touched, k)
+		}
+	}
+	for i := range applied {
+		c := &applied[i]
+		if c.Type != ChangeCreate {
+			count(changedSet(r, c), -1)
+		}
+		if c.Type != ChangeDelete {
+			count(ownedSetOf(c.Record.Name, c.Record.Type), 1)
+		}
+	}
+
+	for _, k := range touched {
+		_, marked := g.owner[k]
+		switch {
+		case g.sets[k] > 0 && !marked:
+			m := libdns.RR{Name: k.markerName(), Type: dynv6.RT_TXT, Data: markerData(p.OwnerID)}
+			dr, err := p.converter().FromLibdns(&m)
+			if err != nil {
+				return err
+			}
+			if _, err = p.recordAdd(ctx, zone, z, &m, dr); err != nil {
+				return err
+			}
+		case g.sets[k] <= 0 && g.owns(p.OwnerID, k):
+			m := g.markers[k]
+			for i := range m {
+				if markerOwner(&m[i]) != p.OwnerID {
+					continue
+				}
+				lr := p.libdnsRecord(&m[i]).RR()
+				if err := p.recordDel(ctx, zone, z, string(m[i].ID), &lr); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// notOwned reports for each record of r whether its RRset is not owned by
+// p.OwnerID, so it is never deleted. Markers are never owned.
+func (p *Provider) notOwned(r []dynv6.Record) []bool {
+	g := newRegistry(r)
+	o := make([]bool, len(r))
+	for i := range r {
+		_, _, marker := parseMarker(&r[i])
+		o[i] = marker || !g.owns(p.OwnerID, ownedSetOf(r[i].Name, r[i].Type))
+	}
+	return o
+}
+
+// ownedOf returns the records of r in RRsets owned by p.OwnerID, without markers.
+func (p *Provider) ownedOf(r []dynv6.Record) []dynv6.Record {
+	o := make([]dynv6.Record, 0, len(r))
+	for i, x := range p.notOwned(r) {
+		if !x {
+			o = append(o, r[i])
+		}
+	}
+	return o
+}
+
+// withoutMarkers returns records without markers, which the registry keeps.
+func withoutMarkers(records []libdns.Record) []libdns.Record {
+	o := make([]libdns.Record, 0, len(records))
+	for _, x := range records {
+		rr := x.RR()
+		if strings.EqualFold(rr.Type, dynv6.RT_TXT) && strings.HasPrefix(rr.Name, ownerPrefix) {
+			continue
+		}
+		o = append(o, x)
+	}
+	return o
+}
+
+// deleted returns the delete changes of the records d, as returned by
+// [Provider.libdnsRecord].
+func deleted(d []libdns.Record) []Change {
+	o := make([]Change, len(d))
+	for i, x := range d {
+		o[i] = Change{Type: ChangeDelete, Record: x.RR(), ID: RecordID(x)}
+	}
+	return o
+}
+
+func markerOwner(r *dynv6.Record) string {
+	_, owner, _ := parseMarker(r)
+	return owner
+}
@@ -0,0 +1,144 @@
+package libdynv6_test
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/ZxwyProject/libdynv6"
+	"github.com/ZxwyProject/libdynv6/dynv6test"
+	"github.com/libdns/libdns"
+)
+
+const ownerZone = `example.dynv6.net`
+
+// newOwned returns a zone holding an RRset created by hand (www A) and one
+// owned by someone else (api A), and a provider with the OwnerID "me".
+func newOwned() *dynv6test.Memory {
+	m := dynv6test.NewMemory(ownerZone)
+	m.AddRecord(ownerZone, dynv6test.Record{Type: `A`, Name: `www`, Data: `192.0.2.1`})
+	m.AddRecord(ownerZone, dynv6test.Record{Type: `A`, Name: `api`, Data: `192.0.2.2`})
+	m.AddRecord(ownerZone, dynv6test.Record{Type: `TXT`, Name: `_owner-a.api`, Data: `heritage=libdynv6,libdynv6/owner=other`})
+	m.OwnerID = `me`
+	return m
+}
+
+func names(m *dynv6test.Memory) []string {
+	var o []string
+	for _, r := range m.Records(ownerZone) {
+		o = append(o, r.Type+` `+r.Name+` `+r.Data)
+	}
+	sort.Strings(o)
+	return o
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func address(name, ip string) libdns.Record {
+	return libdns.Address{Name: name, IP: netip.MustParseAddr(ip)}
+}
+
+func TestOwnerSyncZone(t *testing.T) {
+	ctx := context.Background()
+	m := newOwned()
+	if _, err := m.AppendRecords(ctx, ownerZone, []libdns.Record{address(`app`, `192.0.2.3`)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.SyncZone(ctx, ownerZone, []libdns.Record{address(`app`, `192.0.2.4`)}, libdynv6.SyncOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`A api 192.0.2.2`,
+		`A app 192.0.2.4`,
+		`A www 192.0.2.1`,
+		`TXT _owner-a.api heritage=libdynv6,libdynv6/owner=other`,
+		`TXT _owner-a.app heritage=libdynv6,libdynv6/owner=me`,
+	}
+	if got := names(m); !equal(got, want) {
+		t.Fatalf("after SyncZone:\n%q\nwant\n%q", got, want)
+	}
+
+	if _, err := m.ReplaceAllRecords(ctx, ownerZone, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{
+		`A api 192.0.2.2`,
+		`A www 192.0.2.1`,
+		`TXT _owner-a.api heritage=libdynv6,libdynv6/owner=other`,
+	}
+	if got := names(m); !equal(got, want) {
+		t.Fatalf("after ReplaceAllRecords:\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestOwnerNotOwned(t *testing.T) {
+	ctx := context.Background()
+	m := newOwned()
+	want := names(m)
+	for _, name := range []string{`www`, `api`, `_owner-a.api`} {
+		_, err := m.SetRecords(ctx, ownerZone, []libdns.Record{libdns.TXT{Name: name, Text: `x`}, address(name, `192.0.2.9`)})
+		if !errors.Is(err, libdynv6.ErrNotOwned) {
+			t.Errorf(`SetRecords %s: %v, want ErrNotOwned`, name, err)
+		}
+	}
+	if _, err := m.SyncZone(ctx, ownerZone, []libdns.Record{address(`www`, `192.0.2.9`)}, libdynv6.SyncOptions{}); !errors.Is(err, libdynv6.ErrNotOwned) {
+		t.Errorf(`SyncZone: %v, want ErrNotOwned`, err)
+	}
+	res, err := m.UpsertRecords(ctx, ownerZone, []libdns.Record{address(`api`, `192.0.2.9`)})
+	if err != nil || !errors.Is(res[0].Err, libdynv6.ErrNotOwned) {
+		t.Errorf(`UpsertRecords: %v %v, want ErrNotOwned`, err, res)
+	}
+	if _, err = m.PurgeZone(ctx, ownerZone, libdynv6.PurgeOptions{Confirm: ownerZone}); err != nil {
+		t.Error(err)
+	}
+
+	r, err := m.GetRecords(ctx, ownerZone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, x := range r {
+		c := []libdynv6.Change{{Type: libdynv6.ChangeDelete, Record: x.RR(), ID: libdynv6.RecordID(x)}}
+		if err = m.BatchTx(ctx, ownerZone, c); !errors.Is(err, libdynv6.ErrNotOwned) {
+			t.Errorf(`BatchTx deleting %s: %v, want ErrNotOwned`, x.RR().Name, err)
+		}
+	}
+
+	for _, x := range m.Records(ownerZone) {
+		ref := libdynv6.RecordRef{ZoneID: strconv.FormatInt(x.ZoneID, 10), RecordID: strconv.FormatInt(x.ID, 10)}
+		if err = m.Resources().Delete(ctx, ref); !errors.Is(err, libdynv6.ErrNotOwned) {
+			t.Errorf(`Resources.Delete %s: %v, want ErrNotOwned`, x.Name, err)
+		}
+	}
+
+	if got := names(m); !equal(got, want) {
+		t.Fatalf("records changed:\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestOwnerFailedWrite(t *testing.T) {
+	ctx := context.Background()
+	m := newOwned()
+	want := names(m)
+	m.SetFail(dynv6test.Inject(dynv6test.Fault{Op: libdynv6.OpCreate, N: 1}))
+
+	if _, err := m.AppendRecords(ctx, ownerZone, []libdns.Record{address(`app`, `192.0.2.3`)}); err == nil {
+		t.Fatal(`AppendRecords did not fail`)
+	}
+	if got := names(m); !equal(got, want) {
+		t.Fatalf("marker left by a failed write:\n%q\nwant\n%q", got, want)
+	}
+}
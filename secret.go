@@ -0,0 +1,113 @@
+package libdynv6
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// envPlaceholder matches {env.VAR} and ${VAR}.
+var envPlaceholder = regexp.MustCompile(`\{env\.([A-Za-z_][A-Za-z0-9_]*)\}|\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ExpandEnv replaces the placeholders {env.VAR}, as in Caddy, and ${VAR} in
+// s by the value of the environment variable VAR, or "" if it is unset.
+// Other text, including a bare $VAR, is kept.
+func ExpandEnv(s string) string {
+	if !strings.Contains(s, `{`) {
+		return s
+	}
+	return envPlaceholder.ReplaceAllStringFunc(s, func(m string) string {
+		v := envPlaceholder.FindStringSubmatch(m)
+		return os.Getenv(v[1] + v[2])
+	})
+}
+
+// Redacted replaces tokens when a [Provider] is marshaled to JSON.
+const Redacted = `REDACTED`
+
+// plain is Provider without its JSON methods.
+type plain Provider
+
+// MarshalJSON encodes p like encoding/json would, but with Token and the
+// values of ZoneTokens replaced by Redacted, so config exports and debug
+// dumps never contain tokens. TokenEnv, TokenFile and tokens which are just
+// a placeholder like {env.DYNV6_TOKEN} are kept.
+func (p *Provider) MarshalJSON() ([]byte, error) {
+	var zt map[string]string
+	if len(p.ZoneTokens) > 0 {
+		zt = make(map[string]string, len(p.ZoneTokens))
+		for k, v := range p.ZoneTokens {
+			zt[k] = redact(v)
+		}
+	}
+	t := redact(p.Token)
+	return json.Marshal(struct {
+		*plain
+		Token      string            `json:"token,omitempty"`
+		ZoneTokens map[string]string `json:"zone_tokens,omitempty"`
+	}{(*plain)(p), t, zt})
+}
+
+// redact returns Redacted for the token s, unless s is empty or a placeholder.
+func redact(s string) string {
+	if s == `` || envPlaceholder.FindString(s) == s {
+		return s
+	}
+	return Redacted
+}
+
+// UnmarshalJSON decodes p like encoding/json would. At most one of token,
+// token_env and token_file may be set, and redacted tokens are rejected.
+func (p *Provider) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, (*plain)(p)); err != nil {
+		return err
+	}
+	n := 0
+	for _, s := range []string{p.Token, p.TokenEnv, p.TokenFile} {
+		if s != `` {
+			n++
+		}
+	}
+	if n > 1 {
+		return errors.New(`libdynv6: only one of token, token_env and token_file may be set`)
+	}
+	if p.Token == Redacted {
+		return errors.New(`libdynv6: token was redacted`)
+	}
+	for zone, t := range p.ZoneTokens {
+		if t == Redacted {
+			return fmt.Errorf(`libdynv6: token of zone %s was redacted`, zone)
+		}
+	}
+	return nil
+}
+
+// ResolveToken returns Token, or the token read from TokenEnv or TokenFile,
+// or "" if none is set. Placeholders in Token and TokenFile are expanded,
+// see [ExpandEnv].
+func (p *Provider) ResolveToken() (string, error) {
+	switch {
+	case p.Token != ``:
+		t := ExpandEnv(p.Token)
+		if t == `` {
+			return ``, fmt.Errorf(`libdynv6: token %s expands to nothing`, p.Token)
+		}
+		return t, nil
+	case p.TokenEnv != ``:
+		t := os.Getenv(p.TokenEnv)
+		if t == `` {
+			return ``, fmt.Errorf(`libdynv6: %s is not set`, p.TokenEnv)
+		}
+		return t, nil
+	case p.TokenFile != ``:
+		b, err := os.ReadFile(ExpandEnv(p.TokenFile))
+		if err != nil {
+			return ``, fmt.Errorf(`libdynv6: token file: %v`, err)
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+	return ``, nil
+}
@@ -0,0 +1,78 @@
+package libdynv6
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// Restore modes for [Provider.Restore].
+const (
+	// Restore the RRsets of the snapshot, leave other records alone.
+	RestoreMerge = `merge`
+	// Make the zone exactly match the snapshot, including zone addresses.
+	RestoreExact = `exact`
+)
+
+// Snapshot is a serializable copy of a zone, see [Provider.Snapshot].
+type Snapshot struct {
+	Zone    ZoneInfo    `json:"zone"`
+	Time    time.Time   `json:"time"`
+	Records []libdns.RR `json:"records"`
+}
+
+// Snapshot returns the metadata and all records of zone, for a later
+// [Provider.Restore].
+func (p *Provider) Snapshot(ctx context.Context, zone string) (*Snapshot, error) {
+	p.o.Do(p.init)
+	z, r, err := p.records(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	s := Snapshot{
+		Zone:    zoneInfo(z),
+		Time:    time.Now().UTC(),
+		Records: make([]libdns.RR, len(r)),
+	}
+	for i := range r {
+		s.Records[i] = p.libdnsRecord(&r[i]).RR()
+	}
+	return &s, nil
+}
+
+// Restore reapplies snapshot s to zone, which need not be the zone it was
+// taken from. It returns the plan which was applied.
+func (p *Provider) Restore(ctx context.Context, zone string, s *Snapshot, mode string) (*Plan, error) {
+	r := make([]libdns.Record, len(s.Records))
+	for i := range s.Records {
+		r[i] = s.Records[i]
+	}
+
+	switch mode {
+	case RestoreMerge:
+		pl, err := p.Plan(ctx, zone, r)
+		if err != nil {
+			return nil, err
+		}
+		c, err := p.Apply(ctx, pl)
+		if err != nil {
+			return &Plan{Zone: zone, Changes: c}, err
+		}
+		return pl, nil
+
+	case RestoreExact:
+		pl, err := p.SyncZone(ctx, zone, r, SyncOptions{})
+		if err != nil {
+			return pl, err
+		}
+		if s.Zone.IPv4Address != `` || s.Zone.IPv6Prefix != `` {
+			_, err = p.SetZoneAddresses(ctx, zone, s.Zone.IPv4Address, s.Zone.IPv6Prefix)
+		}
+		return pl, err
+
+	default:
+		return nil, fmt.Errorf(`unknown restore mode %q`, mode)
+	}
+}
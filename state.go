@@ -0,0 +1,147 @@
+package libdynv6
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/libdns/libdns"
+	"gopkg.in/yaml.v3"
+)
+
+// DesiredState is the declarative description of a zone's records,
+// meant to be kept in version control. It is read from YAML or JSON:
+//
+//	zone: example.com
+//	ttl: 60              # default TTL in seconds, optional
+//	records:
+//	  - name: "@"
+//	    type: A
+//	    data: 192.0.2.1
+//	  - name: www
+//	    type: CNAME
+//	    data: example.com.
+//	  - name: "@"
+//	    type: MX
+//	    data: 10 mail.example.com.
+//	    ttl: 3600
+//
+// Record data uses the zone file syntax of [libdns.RR.Data]; host names
+// without a trailing dot are relative to the zone. The apex is named "@",
+// and "" is accepted for it too.
+type DesiredState struct {
+	Zone    string        `json:"zone" yaml:"zone"`
+	TTL     int           `json:"ttl,omitempty" yaml:"ttl,omitempty"` // seconds
+	Records []StateRecord `json:"records" yaml:"records"`
+}
+
+// StateRecord is a single record of a [DesiredState].
+type StateRecord struct {
+	Name string `json:"name" yaml:"name"`
+	Type string `json:"type" yaml:"type"`
+	Data string `json:"data" yaml:"data"`
+	TTL  int    `json:"ttl,omitempty" yaml:"ttl,omitempty"` // seconds, defaults to DesiredState.TTL
+}
+
+// LoadDesiredState reads a YAML or JSON desired state from r and validates it.
+func LoadDesiredState(r io.Reader) (*DesiredState, error) {
+	var s DesiredState
+	d := yaml.NewDecoder(r)
+	d.KnownFields(true)
+	if err := d.Decode(&s); err != nil {
+		return nil, fmt.Errorf(`desired state: %v`, err)
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// LoadDesiredStateFile is like [LoadDesiredState], but reads the file name.
+func LoadDesiredStateFile(name string) (*DesiredState, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadDesiredState(f)
+}
+
+func (s *DesiredState) validate() error {
+	if s.Zone == `` {
+		return fmt.Errorf(`desired state: no zone`)
+	}
+	for i, r := range s.Records {
+		if r.Type == `` {
+			return fmt.Errorf(`desired state: record %d: type is required`, i+1)
+		}
+		if r.TTL < 0 {
+			return fmt.Errorf(`desired state: record %d: negative ttl`, i+1)
+		}
+	}
+	return nil
+}
+
+// LibdnsRecords returns the records of s.
+func (s *DesiredState) LibdnsRecords() []libdns.Record {
+	o := make([]libdns.Record, len(s.Records))
+	for i, r := range s.Records {
+		t := r.TTL
+		if t == 0 {
+			t = s.TTL
+		}
+		o[i] = libdns.RR{
+			Name: r.Name,
+			TTL:  time.Duration(t) * time.Second,
+			Type: r.Type,
+			Data: r.Data,
+		}
+	}
+	return o
+}
+
+// DumpDesiredState returns the current records of zone as a desired state,
+// the starting point for keeping an existing zone in version control.
+func (p *Provider) DumpDesiredState(ctx context.Context, zone string) (*DesiredState, error) {
+	r, err := p.GetRecords(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	s := DesiredState{
+		Zone:    zoneName(zone),
+		TTL:     int(p.ttl() / time.Second),
+		Records: make([]StateRecord, len(r)),
+	}
+	for i, x := range r {
+		rr := x.RR()
+		if rr.Name == `` {
+			rr.Name = `@`
+		}
+		s.Records[i] = StateRecord{Name: rr.Name, Type: rr.Type, Data: rr.Data}
+		if t := int(rr.TTL / time.Second); t != s.TTL {
+			s.Records[i].TTL = t
+		}
+	}
+	return &s, nil
+}
+
+// WriteYAML writes s to w as YAML.
+func (s *DesiredState) WriteYAML(w io.Writer) error {
+	e := yaml.NewEncoder(w)
+	e.SetIndent(2)
+	if err := e.Encode(s); err != nil {
+		return err
+	}
+	return e.Close()
+}
+
+// ApplyDesiredState sets the records of s in its zone, see [Provider.SetRecords].
+// Records not described by s are left untouched.
+func (p *Provider) ApplyDesiredState(ctx context.Context, s *DesiredState) ([]libdns.Record, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return p.SetRecords(ctx, s.Zone, s.LibdnsRecords())
+}
@@ -0,0 +1,119 @@
+package libdynv6
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// API operations, used as keys by [Provider.Stats].
+const (
+	OpZones      = `zones`       // list zones
+	OpZone       = `zone`        // get zone
+	OpZoneUpdate = `zone_update` // update zone
+	OpZoneDelete = `zone_delete` // delete zone
+	OpRecords    = `records`     // list records
+	OpCreate     = `create`      // create record
+	OpUpdate     = `update`      // update record
+	OpDelete     = `delete`      // delete record
+)
+
+// statsWindow is the number of recent samples per operation used for
+// percentiles and error rate.
+const statsWindow = 256
+
+// OpStats are the latency statistics of a single API operation.
+//
+// Calls and Errors count all calls since the provider was initialized,
+// the other fields cover only the most recent calls.
+type OpStats struct {
+	Calls     uint64        `json:"calls"`
+	Errors    uint64        `json:"errors"`
+	ErrorRate float64       `json:"error_rate"`
+	P50       time.Duration `json:"p50"`
+	P95       time.Duration `json:"p95"`
+	Max       time.Duration `json:"max"`
+}
+
+type sample struct {
+	d   time.Duration
+	err bool
+}
+
+type opStats struct {
+	calls, errors uint64
+	w             [statsWindow]sample
+	n             int // valid samples in w
+	i             int // next write position in w
+}
+
+type stats struct {
+	mu sync.Mutex
+	m  map[string]*opStats
+}
+
+func (s *stats) observe(op string, d time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.m == nil {
+		s.m = make(map[string]*opStats)
+	}
+	o := s.m[op]
+	if o == nil {
+		o = &opStats{}
+		s.m[op] = o
+	}
+
+	o.calls++
+	if err != nil {
+		o.errors++
+	}
+	o.w[o.i] = sample{d, err != nil}
+	o.i = (o.i + 1) % statsWindow
+	if o.n < statsWindow {
+		o.n++
+	}
+}
+
+func (s *stats) snapshot() map[string]OpStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := make(map[string]OpStats, len(s.m))
+	for op, o := range s.m {
+		d := make([]time.Duration, o.n)
+		e := 0
+		for i := 0; i < o.n; i++ {
+			d[i] = o.w[i].d
+			if o.w[i].err {
+				e++
+			}
+		}
+		sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+
+		st := OpStats{
+			Calls:  o.calls,
+			Errors: o.errors,
+		}
+		if o.n > 0 {
+			st.ErrorRate = float64(e) / float64(o.n)
+			st.P50 = d[(o.n-1)*50/100]
+			st.P95 = d[(o.n-1)*95/100]
+			st.Max = d[o.n-1]
+		}
+		r[op] = st
+	}
+	return r
+}
+
+// observe records the outcome of an API call started at t.
+func (p *Provider) observe(op string, t time.Time, err error) {
+	p.st.observe(op, time.Since(t), err)
+}
+
+// Stats returns the latency statistics of the API calls made by the provider,
+// keyed by operation (OpZones, OpRecords, OpCreate, ...).
+func (p *Provider) Stats() map[string]OpStats {
+	return p.st.snapshot()
+}
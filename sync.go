@@ -0,0 +1,140 @@
+package libdynv6
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/ZxwyProject/dynv6"
+	"github.com/ZxwyProject/libdynv6/diff"
+	"github.com/libdns/libdns"
+)
+
+// RecordFilter matches records by type and name.
+// An empty field matches every record.
+type RecordFilter struct {
+	Types      []string `json:"types,omitempty"`       // record types
+	Names      []string `json:"names,omitempty"`       // name patterns, see [path.Match]
+	NamePrefix string   `json:"name_prefix,omitempty"` // relative name prefix
+}
+
+func (f *RecordFilter) empty() bool {
+	return len(f.Types) == 0 && len(f.Names) == 0 && f.NamePrefix == ``
+}
+
+func (f *RecordFilter) match(name, typ string) bool {
+	if len(f.Types) > 0 {
+		ok := false
+		for _, t := range f.Types {
+			ok = ok || strings.EqualFold(t, typ)
+		}
+		if !ok {
+			return false
+		}
+	}
+	if !strings.HasPrefix(name, f.NamePrefix) {
+		return false
+	}
+	if len(f.Names) > 0 {
+		ok := false
+		for _, n := range f.Names {
+			m, _ := path.Match(n, name)
+			ok = ok || m
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// SyncOptions controls [Provider.SyncZone].
+type SyncOptions struct {
+	// Only records matching Include (all if empty) and not matching
+	// Exclude (none if empty) are managed. Others are never touched.
+	Include RecordFilter `json:"include,omitempty"`
+	Exclude RecordFilter `json:"exclude,omitempty"`
+
+	// Only plan, do not apply.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+func (o *SyncOptions) managed(name, typ string) bool {
+	return (o.Include.empty() || o.Include.match(name, typ)) &&
+		(o.Exclude.empty() || !o.Exclude.match(name, typ))
+}
+
+// SyncZone makes the managed records of zone exactly match desired: missing
+// records are created, differing ones updated and all others deleted.
+// It returns the plan which was applied, or would be with opts.DryRun.
+// If an error occurs, the plan is returned with only the applied changes.
+// With an OwnerID, only owned RRsets are managed, see [Provider.OwnerID].
+func (p *Provider) SyncZone(ctx context.Context, zone string, desired []libdns.Record, opts SyncOptions) (*Plan, error) {
+	p.o.Do(p.init)
+	unlock, err := p.lockZone(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	z, r, err := p.records(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	m := r
+	if p.OwnerID != `` {
+		m, desired = p.ownedOf(r), withoutMarkers(desired)
+	}
+	pl, err := planSync(p.converter(), zone, m, desired, &opts)
+	if err != nil || opts.DryRun {
+		return pl, err
+	}
+	c, err := p.applyPlan(ctx, z, r, pl)
+	if err != nil {
+		return &Plan{Zone: zone, Changes: c}, err
+	}
+	return pl, nil
+}
+
+// planSync computes the changes turning the managed records of r into desired.
+func planSync(c RecordConverter, zone string, r []dynv6.Record, desired []libdns.Record, opts *SyncOptions) (*Plan, error) {
+	for _, d := range desired {
+		rr := d.RR()
+		if !opts.managed(rr.Name, rr.Type) {
+			return nil, fmt.Errorf(`%s %s is desired but excluded by the sync filters`, rr.Name, rr.Type)
+		}
+	}
+
+	m := make([]dynv6.Record, 0, len(r))
+	for i := range r {
+		if opts.managed(r[i].Name, r[i].Type) {
+			m = append(m, r[i])
+		}
+	}
+
+	cur, want, err := diffInputs(c, zone, m, desired)
+	if err != nil {
+		return nil, err
+	}
+	return &Plan{Zone: zone, Changes: planChanges(m, desired, diff.Diff(cur, want))}, nil
+}
+
+// ReplaceExclude are the records [Provider.ReplaceAllRecords] leaves alone
+// by default: NS and SOA records at the zone apex.
+var ReplaceExclude = RecordFilter{Types: []string{rtNS, `SOA`}, Names: []string{``, `@`}}
+
+// ReplaceAllRecords makes the records of zone exactly equal to records,
+// creating, updating and deleting as needed, except for those matching
+// exclude (ReplaceExclude if nil), which are never touched and must not be
+// in records. Unlike SetRecords, RRsets not in records are deleted.
+// It returns the changes applied, also if an error occurs.
+func (p *Provider) ReplaceAllRecords(ctx context.Context, zone string, records []libdns.Record, exclude *RecordFilter) ([]Change, error) {
+	if exclude == nil {
+		exclude = &ReplaceExclude
+	}
+	pl, err := p.SyncZone(ctx, zone, records, SyncOptions{Exclude: *exclude})
+	if pl == nil {
+		return nil, err
+	}
+	return pl.Changes, err
+}
@@ -0,0 +1,152 @@
+package libdynv6
+
+import (
+	"strings"
+
+	"github.com/ZxwyProject/dynv6"
+)
+
+// canonicalReq returns q with its target name in canonical form, see
+// [canonicalTarget]. q is not modified.
+func canonicalReq(zone string, q *dynv6.RecordReq) *dynv6.RecordReq {
+	d := canonicalData(zone, q.Type, q.Data)
+	if d == q.Data {
+		return q
+	}
+	o := *q
+	o.Data = d
+	return &o
+}
+
+// canonicalData returns the data of a record of type t in canonical form.
+// Only types whose data is a host name are affected.
+func canonicalData(zone, t, data string) string {
+	switch t {
+	case dynv6.RT_CNAME, dynv6.RT_MX, dynv6.RT_SRV, rtNS:
+		return canonicalTarget(zone, data)
+	}
+	return data
+}
+
+// canonicalTarget returns the host name s in lowercase, with IDN labels in
+// ASCII (punycode) and qualified with zone if relative, as dynv6 does, so
+// names differing only cosmetically compare equal. "" and "." are kept.
+// It is only used for comparing, records are written as given.
+func canonicalTarget(zone, s string) string {
+	if s == `` || s == `.` {
+		return s
+	}
+	abs := strings.HasSuffix(s, `.`)
+	l := strings.Split(strings.TrimSuffix(s, `.`), `.`)
+	for i := range l {
+		l[i] = idnaLabel(l[i])
+	}
+	s = strings.Join(l, `.`)
+	if !abs {
+		s += `.` + zoneName(zone)
+	}
+	return s + `.`
+}
+
+// idnaLabel returns the label s in lowercase, and in its ASCII form
+// "xn--..." if it contains non-ASCII characters. No further IDNA
+// mapping or validation is done.
+func idnaLabel(s string) string {
+	s = strings.ToLower(s)
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return `xn--` + punycode(s)
+		}
+	}
+	return s
+}
+
+// Punycode parameters, see RFC 3492 section 5.
+const (
+	pyBase        = 36
+	pyTMin        = 1
+	pyTMax        = 26
+	pySkew        = 38
+	pyDamp        = 700
+	pyInitialBias = 72
+	pyInitialN    = 128
+)
+
+// punycode encodes s as in RFC 3492 section 6.3.
+func punycode(s string) string {
+	rs := []rune(s)
+	var o []byte
+	for _, r := range rs {
+		if r < 0x80 {
+			o = append(o, byte(r))
+		}
+	}
+	b := len(o)
+	h := b
+	if b > 0 {
+		o = append(o, '-')
+	}
+
+	n, delta, bias := rune(pyInitialN), 0, pyInitialBias
+	for h < len(rs) {
+		m := rune(0x7fffffff)
+		for _, r := range rs {
+			if r >= n && r < m {
+				m = r
+			}
+		}
+		delta += int(m-n) * (h + 1)
+		n = m
+		for _, r := range rs {
+			if r < n {
+				delta++
+			}
+			if r != n {
+				continue
+			}
+			q := delta
+			for k := pyBase; ; k += pyBase {
+				t := k - bias
+				if t < pyTMin {
+					t = pyTMin
+				} else if t > pyTMax {
+					t = pyTMax
+				}
+				if q < t {
+					break
+				}
+				o = append(o, pyDigit(t+(q-t)%(pyBase-t)))
+				q = (q - t) / (pyBase - t)
+			}
+			o = append(o, pyDigit(q))
+			bias = pyAdapt(delta, h+1, h == b)
+			delta = 0
+			h++
+		}
+		delta++
+		n++
+	}
+	return string(o)
+}
+
+func pyAdapt(delta, n int, first bool) int {
+	if first {
+		delta /= pyDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / n
+	k := 0
+	for delta > (pyBase-pyTMin)*pyTMax/2 {
+		delta /= pyBase - pyTMin
+		k += pyBase
+	}
+	return k + (pyBase-pyTMin+1)*delta/(delta+pySkew)
+}
+
+func pyDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
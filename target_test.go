@@ -0,0 +1,43 @@
+package libdynv6
+
+import "testing"
+
+// RFC 3492 section 7.1
+var punycodeTests = []struct{ in, out string }{
+	{`ليهمابتكلموشعربي؟`, `egbpdaj6bu4bxfgehfvwxn`},
+	{`他们为什么不说中文`, `ihqwcrb4cv8a8dqg056pqjye`},
+	{`他們爲什麽不說中文`, `ihqwctvzc91f659drss3x8bo0yb`},
+	{`Pročprostěnemluvíčesky`, `Proprostnemluvesky-uyb24dma41a`},
+	{`למההםפשוטלאמדבריםעברית`, `4dbcagdahymbxekheh6e0a7fei0b`},
+	{`3年B組金八先生`, `3B-ww4c5e180e575a65lsy2b`},
+	{`安室奈美恵-with-SUPER-MONKEYS`, `-with-SUPER-MONKEYS-pc58ag80a8qai00g7n9n`},
+	{`Hello-Another-Way-それぞれの場所`, `Hello-Another-Way--fc4qua05auwb3674vfr0b`},
+	{`ひとつ屋根の下2`, `2-u9tlzr9756bt3uc0v`},
+	{`MajiでKoiする5秒前`, `MajiKoi5-783gue6qz075azm5e`},
+	{`パフィーdeルンバ`, `de-jg4avhby1noc0d`},
+	{`そのスピードで`, `d9juau41awczczp`},
+	{`-> $1.00 <-`, `-> $1.00 <--`},
+}
+
+func TestPunycode(t *testing.T) {
+	for _, c := range punycodeTests {
+		if got := punycode(c.in); got != c.out {
+			t.Errorf(`punycode(%q) = %q, want %q`, c.in, got, c.out)
+		}
+	}
+}
+
+func TestCanonicalTarget(t *testing.T) {
+	for _, c := range []struct{ in, out string }{
+		{``, ``},
+		{`.`, `.`},
+		{`Mail.Example.NET.`, `mail.example.net.`},
+		{`mail`, `mail.example.dynv6.net.`},
+		{`bücher.example.`, `xn--bcher-kva.example.`},
+		{`Bücher.example.`, `xn--bcher-kva.example.`},
+	} {
+		if got := canonicalTarget(`example.dynv6.net`, c.in); got != c.out {
+			t.Errorf(`canonicalTarget(%q) = %q, want %q`, c.in, got, c.out)
+		}
+	}
+}
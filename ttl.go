@@ -0,0 +1,64 @@
+package libdynv6
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/ZxwyProject/dynv6"
+	"github.com/libdns/libdns"
+	"github.com/miekg/dns"
+)
+
+// ttl returns the TTL reported for records.
+func (p *Provider) ttl() time.Duration {
+	if p.TTL > 0 {
+		return p.TTL
+	}
+	return ttl
+}
+
+// libdnsRecord converts r with the configured TTL.
+func (p *Provider) libdnsRecord(r *dynv6.Record) Record {
+	o := convertRecord(p.converter(), r)
+	o.TTL = p.ttl()
+	return o
+}
+
+// ServedTTL returns the TTL of the RRset name and rType in zone as served
+// by the first authoritative nameserver that answers (see [Propagation.Nameservers]).
+// name is relative to the zone.
+func (p *Provider) ServedTTL(ctx context.Context, zone, name, rType string) (time.Duration, error) {
+	t, ok := dns.StringToType[rType]
+	if !ok {
+		return 0, fmt.Errorf(`%w: %s`, ErrUnsupportedType, rType)
+	}
+	ns := p.Propagation.Nameservers
+	if len(ns) == 0 {
+		ns = Nameservers
+	}
+	origin := dns.Fqdn(zoneName(zone))
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(libdns.AbsoluteName(name, origin)), t)
+	m.RecursionDesired = false
+	c := dns.Client{Timeout: 5 * time.Second}
+
+	var err error
+	for _, s := range ns {
+		if _, _, e := net.SplitHostPort(s); e != nil {
+			s = net.JoinHostPort(s, `53`)
+		}
+		var r *dns.Msg
+		if r, _, err = c.ExchangeContext(ctx, m, s); err != nil {
+			continue
+		}
+		for _, a := range r.Answer {
+			if h := a.Header(); h.Rrtype == t {
+				return time.Duration(h.Ttl) * time.Second, nil
+			}
+		}
+		return 0, ErrRecordNotFound
+	}
+	return 0, err
+}
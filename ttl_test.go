@@ -0,0 +1,65 @@
+package libdynv6
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ZxwyProject/dynv6"
+	"github.com/miekg/dns"
+)
+
+func TestProviderTTL(t *testing.T) {
+	r := &dynv6.Record{Type: dynv6.RT_A, Name: `www`, Data: `192.0.2.1`}
+	p := new(Provider)
+	if got := p.libdnsRecord(r).TTL; got != DefaultTTL {
+		t.Errorf(`TTL %v, want the default %v`, got, DefaultTTL)
+	}
+	p.TTL = 5 * time.Minute
+	if got := p.libdnsRecord(r).TTL; got != p.TTL {
+		t.Errorf(`TTL %v, want %v`, got, p.TTL)
+	}
+}
+
+// serveDNS answers A queries for www.example.dynv6.net. with a TTL of 300s
+// on a local nameserver and returns its address.
+func serveDNS(t *testing.T) string {
+	pc, err := net.ListenPacket(`udp`, `127.0.0.1:0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &dns.Server{PacketConn: pc, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, q *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(q)
+		m.Authoritative = true
+		if q.Question[0].Name == `www.example.dynv6.net.` && q.Question[0].Qtype == dns.TypeA {
+			rr, _ := dns.NewRR(`www.example.dynv6.net. 300 IN A 192.0.2.1`)
+			m.Answer = append(m.Answer, rr)
+		}
+		w.WriteMsg(m)
+	})}
+	started := make(chan struct{})
+	s.NotifyStartedFunc = func() { close(started) }
+	go s.ActivateAndServe()
+	<-started
+	t.Cleanup(func() { s.Shutdown() })
+	return pc.LocalAddr().String()
+}
+
+func TestServedTTL(t *testing.T) {
+	p := &Provider{Propagation: Propagation{Nameservers: []string{serveDNS(t)}}}
+	ctx := context.Background()
+
+	got, err := p.ServedTTL(ctx, `example.dynv6.net`, `www`, `A`)
+	if err != nil || got != 300*time.Second {
+		t.Errorf(`ServedTTL = %v, %v, want 5m0s`, got, err)
+	}
+	if _, err = p.ServedTTL(ctx, `example.dynv6.net`, `www`, `AAAA`); err != ErrRecordNotFound {
+		t.Errorf(`ServedTTL of a missing RRset: %v, want ErrRecordNotFound`, err)
+	}
+	if _, err = p.ServedTTL(ctx, `example.dynv6.net`, `www`, `BOGUS`); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf(`ServedTTL of an unknown type: %v, want ErrUnsupportedType`, err)
+	}
+}
@@ -0,0 +1,123 @@
+package libdynv6
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ZxwyProject/dynv6"
+)
+
+// TxError is returned by [Provider.BatchTx] if a change failed.
+type TxError struct {
+	Err    error  // error of the failed change
+	Failed Change // the failed change
+
+	RolledBack []Change // inverse changes applied to undo earlier changes
+	NotUndone  []Change // inverse changes which failed, leaving earlier changes in place
+	UndoErr    error    // errors of the failed inverse changes
+}
+
+func (e *TxError) Error() string {
+	s := fmt.Sprintf(`%s failed: %v`, e.Failed.String(), e.Err)
+	if len(e.NotUndone) > 0 {
+		s += fmt.Sprintf(`; %d change(s) could not be rolled back: %v`, len(e.NotUndone), e.UndoErr)
+	}
+	return s
+}
+
+func (e *TxError) Unwrap() error { return e.Err }
+
+// BatchTx applies changes to zone in the given order. If one fails, the
+// earlier ones are rolled back in reverse order: created records are deleted,
+// deleted records are recreated (with a new ID) and updates are reverted to
+// their Old record. The error is a *[TxError] reporting what could not be undone.
+//
+// Updates must carry Old and ID, deletes ID, as in a [Plan]. The rollback
+// uses ctx, so it cannot undo anything once ctx is canceled. With an
+// OwnerID, nothing is applied if a change touches an RRset not owned.
+func (p *Provider) BatchTx(ctx context.Context, zone string, changes []Change) error {
+	for i := range changes {
+		c := &changes[i]
+		switch {
+		case c.Type == ChangeUpdate && (c.Old == nil || c.ID == ``):
+			return fmt.Errorf(`libdynv6: update %d needs Old and ID`, i)
+		case c.Type == ChangeDelete && c.ID == ``:
+			return fmt.Errorf(`libdynv6: delete %d needs ID`, i)
+		case c.Type != ChangeCreate && c.Type != ChangeUpdate && c.Type != ChangeDelete:
+			return fmt.Errorf(`libdynv6: change %d has unknown type %q`, i, c.Type)
+		}
+	}
+
+	p.o.Do(p.init)
+	unlock, err := p.lockZone(ctx, zone)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	z, err := p.zone(ctx, zone)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.guarded(ctx, zone, z, nil, changes, func() ([]Change, error) {
+		undo := make([]Change, 0, len(changes))
+		for i := range changes {
+			inv, err := p.txApply(ctx, zone, z, &changes[i])
+			if err != nil {
+				return nil, p.rollback(ctx, zone, z, undo, &TxError{Err: err, Failed: changes[i]})
+			}
+			undo = append(undo, inv)
+		}
+		return changes, nil
+	})
+	return err
+}
+
+// txApply applies c and returns its inverse.
+func (p *Provider) txApply(ctx context.Context, zone string, z *dynv6.Zone, c *Change) (Change, error) {
+	switch c.Type {
+	case ChangeCreate:
+		dr, err := p.converter().FromLibdns(&c.Record)
+		if err != nil {
+			return Change{}, err
+		}
+		o, err := p.recordAdd(ctx, zone, z, &c.Record, dr)
+		if err != nil {
+			return Change{}, err
+		}
+		return Change{Type: ChangeDelete, Record: c.Record, ID: string(o.ID)}, nil
+
+	case ChangeUpdate:
+		if err := p.applyChange(ctx, zone, z, c); err != nil {
+			return Change{}, err
+		}
+		r := c.Record
+		return Change{Type: ChangeUpdate, Record: *c.Old, Old: &r, ID: c.ID}, nil
+
+	default:
+		if err := p.applyChange(ctx, zone, z, c); err != nil {
+			return Change{}, err
+		}
+		return Change{Type: ChangeCreate, Record: c.Record}, nil
+	}
+}
+
+// rollback applies the inverse changes undo in reverse order and completes e.
+func (p *Provider) rollback(ctx context.Context, zone string, z *dynv6.Zone, undo []Change, e *TxError) error {
+	var errs []error
+	for i := len(undo) - 1; i >= 0; i-- {
+		c := &undo[i]
+		if err := p.applyChange(ctx, zone, z, c); err != nil {
+			e.NotUndone = append(e.NotUndone, *c)
+			errs = append(errs, fmt.Errorf(`%s: %v`, c.String(), err))
+			continue
+		}
+		e.RolledBack = append(e.RolledBack, *c)
+	}
+	e.UndoErr = errors.Join(errs...)
+	if dynv6.Debug {
+		dynv6.DbgLog.Println(`[Dynv6-debug/libdns] BatchTx:`, e)
+	}
+	return e
+}
@@ -0,0 +1,41 @@
+package update
+
+import (
+	"context"
+	"fmt"
+)
+
+// Backend publishes addresses for a host.
+type Backend interface {
+	// Update publishes a for hostname and reports whether anything changed.
+	Update(ctx context.Context, hostname string, a Addrs) (changed bool, err error)
+}
+
+// Protocols accepted by [New].
+const (
+	ProtoUpdate  = `update`  // dynv6 update API, see [Client]
+	ProtoDynDNS2 = `dyndns2` // DynDNS2 protocol, see [DynDNS2]
+	ProtoRFC2136 = `rfc2136` // TSIG-signed DNS UPDATE, see [RFC2136]
+)
+
+// New returns the Backend for protocol, which defaults to ProtoUpdate.
+// ProtoRFC2136 needs a TSIG key and is configured through [RFC2136] directly.
+func New(protocol, token string) (Backend, error) {
+	switch protocol {
+	case ``, ProtoUpdate:
+		return &Client{Token: token}, nil
+	case ProtoDynDNS2:
+		return &DynDNS2{Token: token}, nil
+	case ProtoRFC2136:
+		return nil, fmt.Errorf(`update protocol %q requires a TSIG key, use update.RFC2136`, protocol)
+	default:
+		return nil, fmt.Errorf(`unknown update protocol %q`, protocol)
+	}
+}
+
+// Interface guards
+var (
+	_ Backend = (*Client)(nil)
+	_ Backend = (*DynDNS2)(nil)
+	_ Backend = (*RFC2136)(nil)
+)
@@ -0,0 +1,78 @@
+package update
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ZxwyProject/dynv6"
+)
+
+// DynDNS2Endpoint is the default dynv6 DynDNS2 endpoint.
+const DynDNS2Endpoint = `https://dynv6.com/nic/update`
+
+// DynDNS2 updates hosts through the DynDNS2-compatible protocol, as spoken by
+// most routers and ddclient. It does not support IPv6 prefixes.
+type DynDNS2 struct {
+	//# HTTP Token
+	//
+	// Sent as basic auth password. You can get it at https://dynv6.com/keys
+	Token string `json:"token,omitempty"`
+
+	Endpoint   string       `json:"endpoint,omitempty"` // defaults to DynDNS2Endpoint
+	HTTPClient *http.Client `json:"-"`                  // defaults to http.DefaultClient
+}
+
+// Update publishes a for hostname. An Auto or empty IPv4 lets dynv6 use the
+// source address of the request.
+func (c *DynDNS2) Update(ctx context.Context, hostname string, a Addrs) (bool, error) {
+	if a.empty() {
+		return false, ErrNoAddress
+	}
+	if a.IPv6Prefix != `` && a.IPv4 == `` && a.IPv6 == `` {
+		return false, fmt.Errorf(`dyndns2: IPv6 prefix updates are not supported`)
+	}
+	if c.Token == `` {
+		return false, ErrNoToken
+	}
+
+	q := url.Values{}
+	q.Set(`hostname`, strings.TrimSuffix(hostname, `.`))
+	if a.IPv4 != `` && a.IPv4 != Auto {
+		q.Set(`myip`, a.IPv4)
+	}
+	if a.IPv6 != `` && a.IPv6 != Auto {
+		q.Set(`myipv6`, a.IPv6)
+	}
+
+	u := c.Endpoint
+	if u == `` {
+		u = DynDNS2Endpoint
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u+`?`+q.Encode(), nil)
+	if err != nil {
+		return false, err
+	}
+	req.SetBasicAuth(`none`, c.Token)
+
+	body, err := do(c.HTTPClient, req)
+	if err != nil {
+		return false, err
+	}
+	if dynv6.Debug {
+		dynv6.DbgLog.Println(`[Dynv6-debug/dyndns2]`, hostname, body)
+	}
+
+	code, _, _ := strings.Cut(body, ` `)
+	switch code {
+	case `good`:
+		return true, nil
+	case `nochg`:
+		return false, nil
+	default:
+		// badauth, nohost, notfqdn, abuse, 911, ...
+		return false, fmt.Errorf(`dyndns2: %s`, body)
+	}
+}
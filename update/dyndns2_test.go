@@ -0,0 +1,68 @@
+package update
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDynDNS2(t *testing.T) {
+	for _, c := range []struct {
+		a       Addrs
+		status  int
+		body    string
+		query   string
+		changed bool
+		err     bool
+	}{
+		{Addrs{IPv4: `192.0.2.1`}, 200, `good 192.0.2.1`, `hostname=host.dynv6.net&myip=192.0.2.1`, true, false},
+		{Addrs{IPv4: Auto, IPv6: `2001:db8::1`}, 200, `nochg 2001:db8::1`, `hostname=host.dynv6.net&myipv6=2001%3Adb8%3A%3A1`, false, false},
+		{Addrs{IPv4: `192.0.2.1`}, 200, `badauth`, `hostname=host.dynv6.net&myip=192.0.2.1`, false, true},
+		{Addrs{IPv4: `192.0.2.1`}, 200, `nohost`, `hostname=host.dynv6.net&myip=192.0.2.1`, false, true},
+		{Addrs{IPv4: `192.0.2.1`}, 500, `911`, `hostname=host.dynv6.net&myip=192.0.2.1`, false, true},
+	} {
+		var query, user, pass string
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query = r.URL.RawQuery
+			user, pass, _ = r.BasicAuth()
+			w.WriteHeader(c.status)
+			fmt.Fprint(w, c.body)
+		}))
+		cl := &DynDNS2{Token: `tok`, Endpoint: s.URL, HTTPClient: s.Client()}
+		changed, err := cl.Update(context.Background(), `host.dynv6.net.`, c.a)
+		s.Close()
+		if changed != c.changed || (err != nil) != c.err {
+			t.Errorf(`%s: %v, %v`, c.body, changed, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), c.body) {
+			t.Errorf(`%s: body missing in error %q`, c.body, err)
+		}
+		if query != c.query {
+			t.Errorf(`%s: query %s, want %s`, c.body, query, c.query)
+		}
+		if user != `none` || pass != `tok` {
+			t.Errorf(`%s: basic auth %s:%s, want none:tok`, c.body, user, pass)
+		}
+	}
+}
+
+func TestDynDNS2Invalid(t *testing.T) {
+	for _, c := range []struct {
+		token string
+		a     Addrs
+		err   error
+	}{
+		{`tok`, Addrs{}, ErrNoAddress},
+		{`tok`, Addrs{IPv6Prefix: `2001:db8::/64`}, nil},
+		{``, Addrs{IPv4: Auto}, ErrNoToken},
+	} {
+		cl := &DynDNS2{Token: c.token, Endpoint: `http://127.0.0.1:1`}
+		_, err := cl.Update(context.Background(), `host.dynv6.net`, c.a)
+		if err == nil || (c.err != nil && err != c.err) {
+			t.Errorf(`%+v: %v, want %v`, c.a, err, c.err)
+		}
+	}
+}
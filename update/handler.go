@@ -0,0 +1,136 @@
+package update
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/ZxwyProject/dynv6"
+)
+
+// Handler accepts DynDNS2-style update callbacks, as sent by FRITZ!Box
+// routers or ddclient, and forwards them to Backend. Point the router at e.g.
+//
+//	https://myhost/nic/update?hostname=<domain>&myip=<ipaddr>,<ip6addr>&ipv6prefix=<ip6lanprefix>
+//
+// Addresses may be passed as myip (comma separated, IPv4 and/or IPv6),
+// myipv6 and ipv6prefix. Without any address, the client address is used.
+// Responses use the DynDNS2 return codes (good, nochg, badauth, ...).
+type Handler struct {
+	Backend Backend
+
+	// Basic auth credentials the router must send. Without a Password,
+	// every request is rejected.
+	Username string
+	Password string
+
+	// Hostnames that may be updated. Any if empty.
+	Hosts []string
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(`Content-Type`, `text/plain; charset=utf-8`)
+
+	user, pass, ok := r.BasicAuth()
+	if !ok || h.Password == `` || subtle.ConstantTimeCompare([]byte(user), []byte(h.Username)) != 1 ||
+		subtle.ConstantTimeCompare([]byte(pass), []byte(h.Password)) != 1 {
+		w.Header().Set(`WWW-Authenticate`, `Basic realm="dynv6"`)
+		http.Error(w, `badauth`, http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	hosts := strings.Split(q.Get(`hostname`), `,`)
+	for _, host := range hosts {
+		if host == `` || !strings.Contains(host, `.`) {
+			http.Error(w, `notfqdn`, http.StatusBadRequest)
+			return
+		}
+		if !h.allowed(host) {
+			http.Error(w, `nohost`, http.StatusForbidden)
+			return
+		}
+	}
+
+	a, err := callbackAddrs(q.Get(`myip`), q.Get(`myipv6`), q.Get(`ipv6prefix`))
+	if err != nil {
+		http.Error(w, `dnserr`, http.StatusBadRequest)
+		return
+	}
+	if a.empty() {
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			if ip, err := netip.ParseAddr(host); err == nil {
+				if ip = ip.Unmap(); ip.Is4() {
+					a.IPv4 = ip.String()
+				} else {
+					a.IPv6 = ip.String()
+				}
+			}
+		}
+	}
+
+	changed := false
+	for _, host := range hosts {
+		c, err := h.Backend.Update(r.Context(), host, a)
+		if err != nil {
+			if dynv6.Debug {
+				dynv6.DbgLog.Println(`[Dynv6-debug/handler]`, host, err)
+			}
+			http.Error(w, `911`, http.StatusBadGateway)
+			return
+		}
+		changed = changed || c
+	}
+
+	ip := a.IPv4
+	if ip == `` {
+		ip = a.IPv6
+	}
+	if changed {
+		w.Write([]byte(`good ` + ip + "\n"))
+	} else {
+		w.Write([]byte(`nochg ` + ip + "\n"))
+	}
+}
+
+func (h *Handler) allowed(host string) bool {
+	if len(h.Hosts) == 0 {
+		return true
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), `.`)
+	for _, x := range h.Hosts {
+		if strings.TrimSuffix(strings.ToLower(x), `.`) == host {
+			return true
+		}
+	}
+	return false
+}
+
+// callbackAddrs parses the address parameters of a callback.
+func callbackAddrs(myip, myipv6, prefix string) (Addrs, error) {
+	var a Addrs
+	for _, s := range strings.Split(myip+`,`+myipv6, `,`) {
+		if s = strings.TrimSpace(s); s == `` {
+			continue
+		}
+		ip, err := netip.ParseAddr(s)
+		if err != nil {
+			return a, err
+		}
+		if ip = ip.Unmap(); ip.Is4() {
+			a.IPv4 = ip.String()
+		} else {
+			a.IPv6 = ip.String()
+		}
+	}
+	if prefix = strings.TrimSpace(prefix); prefix != `` {
+		p, err := netip.ParsePrefix(prefix)
+		if err != nil {
+			return a, err
+		}
+		a.IPv6Prefix = p.String()
+	}
+	return a, nil
+}
@@ -0,0 +1,81 @@
+package update
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeBackend records updates and reports changed.
+type fakeBackend struct {
+	changed bool
+	err     error
+	hosts   []string
+	addrs   []Addrs
+}
+
+func (b *fakeBackend) Update(_ context.Context, hostname string, a Addrs) (bool, error) {
+	b.hosts = append(b.hosts, hostname)
+	b.addrs = append(b.addrs, a)
+	return b.changed, b.err
+}
+
+func serve(h http.Handler, target, user, pass string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	if user != `` || pass != `` {
+		r.SetBasicAuth(user, pass)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestHandlerWithoutPassword(t *testing.T) {
+	b := new(fakeBackend)
+	h := &Handler{Backend: b}
+	for _, c := range []struct{ user, pass string }{{``, ``}, {`user`, ``}, {``, `pass`}} {
+		r := httptest.NewRequest(http.MethodGet, `/nic/update?hostname=host.dynv6.net&myip=192.0.2.1`, nil)
+		r.SetBasicAuth(c.user, c.pass)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf(`%q:%q: status %d, want 401`, c.user, c.pass, w.Code)
+		}
+	}
+	if len(b.hosts) != 0 {
+		t.Fatalf(`updated %v`, b.hosts)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	for _, c := range []struct {
+		name, target, user, pass string
+		changed                  bool
+		code                     int
+		body                     string
+		want                     Addrs
+	}{
+		{`wrong password`, `/?hostname=host.dynv6.net`, `router`, `wrong`, false, 401, `badauth`, Addrs{}},
+		{`no auth`, `/?hostname=host.dynv6.net`, ``, ``, false, 401, `badauth`, Addrs{}},
+		{`not fqdn`, `/?hostname=host`, `router`, `pw`, false, 400, `notfqdn`, Addrs{}},
+		{`other host`, `/?hostname=other.dynv6.net`, `router`, `pw`, false, 403, `nohost`, Addrs{}},
+		{`bad address`, `/?hostname=host.dynv6.net&myip=nope`, `router`, `pw`, false, 400, `dnserr`, Addrs{}},
+		{`good`, `/?hostname=host.dynv6.net&myip=192.0.2.1,2001:db8::1&ipv6prefix=2001:db8::/64`, `router`, `pw`, true, 200, `good 192.0.2.1`,
+			Addrs{IPv4: `192.0.2.1`, IPv6: `2001:db8::1`, IPv6Prefix: `2001:db8::/64`}},
+		{`nochg`, `/?hostname=host.dynv6.net&myipv6=2001:db8::1`, `router`, `pw`, false, 200, `nochg 2001:db8::1`, Addrs{IPv6: `2001:db8::1`}},
+		{`client address`, `/?hostname=host.dynv6.net`, `router`, `pw`, true, 200, `good 192.0.2.1`, Addrs{IPv4: `192.0.2.1`}},
+	} {
+		b := &fakeBackend{changed: c.changed}
+		h := &Handler{Backend: b, Username: `router`, Password: `pw`, Hosts: []string{`host.dynv6.net.`}}
+		w := serve(h, c.target, c.user, c.pass)
+		if w.Code != c.code || strings.TrimSpace(w.Body.String()) != c.body {
+			t.Errorf(`%s: %d %q, want %d %q`, c.name, w.Code, w.Body.String(), c.code, c.body)
+			continue
+		}
+		if c.code == 200 && (len(b.addrs) != 1 || b.addrs[0] != c.want) {
+			t.Errorf(`%s: updated %v, want %v`, c.name, b.addrs, c.want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// RFC2136Server is the default dynv6 server for DNS UPDATE messages.
+const RFC2136Server = `ns1.dynv6.com:53`
+
+// ErrNoKey is returned by [RFC2136] updates without a TSIG key.
+var ErrNoKey = errors.New(`no TSIG key provided`)
+
+// RFC2136 updates hosts with TSIG-signed DNS UPDATE messages (RFC 2136),
+// without using HTTPS at all. Create a TSIG key at https://dynv6.com/keys.
+//
+// Auto addresses and IPv6 prefixes are not supported. Every update replaces
+// the A/AAAA RRset of the host, so it always reports a change.
+type RFC2136 struct {
+	Server string `json:"server,omitempty"` // defaults to RFC2136Server
+	Net    string `json:"net,omitempty"`    // "udp" or "tcp" (default)
+	Zone   string `json:"zone,omitempty"`   // defaults to the hostname
+
+	KeyName      string `json:"key_name,omitempty"`
+	KeyAlgorithm string `json:"key_algorithm,omitempty"` // defaults to hmac-sha256
+	Secret       string `json:"secret,omitempty"`        // base64
+
+	TTL uint32 `json:"ttl,omitempty"` // defaults to 60
+}
+
+// Update publishes a for hostname.
+func (c *RFC2136) Update(ctx context.Context, hostname string, a Addrs) (bool, error) {
+	if a.empty() {
+		return false, ErrNoAddress
+	}
+	if a.IPv6Prefix != `` {
+		return false, fmt.Errorf(`rfc2136: IPv6 prefix updates are not supported`)
+	}
+	if c.KeyName == `` || c.Secret == `` {
+		return false, ErrNoKey
+	}
+
+	name := dns.Fqdn(hostname)
+	zone := name
+	if c.Zone != `` {
+		zone = dns.Fqdn(c.Zone)
+	}
+	ttl := c.TTL
+	if ttl == 0 {
+		ttl = 60
+	}
+
+	m := new(dns.Msg)
+	m.SetUpdate(zone)
+
+	if a.IPv4 != `` {
+		ip := net.ParseIP(a.IPv4)
+		if ip == nil || ip.To4() == nil {
+			return false, fmt.Errorf(`rfc2136: invalid IPv4 address %q`, a.IPv4)
+		}
+		rr := []dns.RR{&dns.A{
+			Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: ttl},
+			A:   ip.To4(),
+		}}
+		m.RemoveRRset(rr)
+		m.Insert(rr)
+	}
+	if a.IPv6 != `` {
+		ip := net.ParseIP(a.IPv6)
+		if ip == nil || ip.To4() != nil {
+			return false, fmt.Errorf(`rfc2136: invalid IPv6 address %q`, a.IPv6)
+		}
+		rr := []dns.RR{&dns.AAAA{
+			Hdr:  dns.RR_Header{Name: name, Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: ttl},
+			AAAA: ip,
+		}}
+		m.RemoveRRset(rr)
+		m.Insert(rr)
+	}
+
+	key := dns.Fqdn(c.KeyName)
+	alg := dns.HmacSHA256
+	if c.KeyAlgorithm != `` {
+		alg = dns.Fqdn(strings.ToLower(c.KeyAlgorithm))
+	}
+	m.SetTsig(key, alg, 300, time.Now().Unix())
+
+	cl := dns.Client{
+		Net:        c.Net,
+		TsigSecret: map[string]string{key: c.Secret},
+	}
+	if cl.Net == `` {
+		cl.Net = `tcp`
+	}
+	server := c.Server
+	if server == `` {
+		server = RFC2136Server
+	}
+
+	r, _, err := cl.ExchangeContext(ctx, m, server)
+	if err != nil {
+		return false, fmt.Errorf(`rfc2136: %v`, err)
+	}
+	if r.Rcode != dns.RcodeSuccess {
+		return false, fmt.Errorf(`rfc2136: %s`, dns.RcodeToString[r.Rcode])
+	}
+	return true, nil
+}
@@ -0,0 +1,95 @@
+package update
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+const testSecret = `c2VjcmV0` // "secret"
+
+// serveUpdates starts a DNS server accepting updates signed with
+// testSecret, and returns its address and the accepted messages.
+func serveUpdates(t *testing.T) (string, chan *dns.Msg) {
+	pc, err := net.ListenPacket(`udp`, `127.0.0.1:0`)
+	if err != nil {
+		t.Skip(err)
+	}
+	got := make(chan *dns.Msg, 1)
+	started := make(chan struct{})
+	s := &dns.Server{
+		PacketConn:        pc,
+		TsigSecret:        map[string]string{`key.`: testSecret},
+		NotifyStartedFunc: func() { close(started) },
+		MsgAcceptFunc:     func(dns.Header) dns.MsgAcceptAction { return dns.MsgAccept },
+		Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+			m := new(dns.Msg)
+			m.SetReply(r)
+			if r.IsTsig() == nil || w.TsigStatus() != nil {
+				m.Rcode = dns.RcodeNotAuth
+			} else {
+				got <- r
+			}
+			w.WriteMsg(m)
+		}),
+	}
+	go s.ActivateAndServe()
+	<-started
+	t.Cleanup(func() { s.Shutdown() })
+	return pc.LocalAddr().String(), got
+}
+
+func TestRFC2136(t *testing.T) {
+	addr, got := serveUpdates(t)
+	c := &RFC2136{Server: addr, Net: `udp`, Zone: `dynv6.net`, KeyName: `key`, Secret: testSecret, TTL: 30}
+	changed, err := c.Update(context.Background(), `host.dynv6.net`, Addrs{IPv4: `192.0.2.1`, IPv6: `2001:db8::1`})
+	if err != nil || !changed {
+		t.Fatalf(`%v, %v`, changed, err)
+	}
+	m := <-got
+	if z := m.Question[0].Name; z != `dynv6.net.` {
+		t.Errorf(`zone %s, want dynv6.net.`, z)
+	}
+	want := []string{
+		"host.dynv6.net.\t0\tCLASS255\tA\t",
+		"host.dynv6.net.\t30\tIN\tA\t192.0.2.1",
+		"host.dynv6.net.\t0\tCLASS255\tAAAA\t",
+		"host.dynv6.net.\t30\tIN\tAAAA\t2001:db8::1",
+	}
+	if len(m.Ns) != len(want) {
+		t.Fatalf(`update section %v`, m.Ns)
+	}
+	for i, rr := range m.Ns {
+		if rr.String() != want[i] {
+			t.Errorf(`update %d: %q, want %q`, i, rr, want[i])
+		}
+	}
+
+	c.Secret = `b3RoZXI=`
+	if _, err = c.Update(context.Background(), `host.dynv6.net`, Addrs{IPv4: `192.0.2.1`}); err == nil {
+		t.Error(`update with a wrong secret did not fail`)
+	}
+}
+
+func TestRFC2136Invalid(t *testing.T) {
+	for _, c := range []struct {
+		key string
+		a   Addrs
+		err error
+	}{
+		{`key`, Addrs{}, ErrNoAddress},
+		{``, Addrs{IPv4: `192.0.2.1`}, ErrNoKey},
+		{`key`, Addrs{IPv4: Auto}, nil},
+		{`key`, Addrs{IPv4: `2001:db8::1`}, nil},
+		{`key`, Addrs{IPv6: `192.0.2.1`}, nil},
+		{`key`, Addrs{IPv6Prefix: `2001:db8::/64`}, nil},
+	} {
+		cl := &RFC2136{Server: `127.0.0.1:1`, KeyName: c.key, Secret: testSecret}
+		_, err := cl.Update(context.Background(), `host.dynv6.net`, c.a)
+		if err == nil || (c.err != nil && err != c.err) {
+			t.Errorf(`%+v: %v, want %v`, c.a, err, c.err)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Package update implements the dynv6 update API for classic dynamic DNS
+// host updates, see https://dynv6.com/docs/apis.
+package update
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ZxwyProject/dynv6"
+)
+
+// Endpoint is the default dynv6 update API endpoint.
+const Endpoint = `https://dynv6.com/api/update`
+
+// Auto lets dynv6 use the source address of the update request.
+const Auto = `auto`
+
+var (
+	// ErrNoAddress is returned if an update carries no address at all.
+	ErrNoAddress = errors.New(`no address to update`)
+	// ErrNoToken is returned by updates without a token.
+	ErrNoToken = errors.New(`no token provided`)
+)
+
+// Addrs are the addresses to publish for a host. Empty fields are not sent,
+// so dynv6 leaves them unchanged.
+type Addrs struct {
+	IPv4       string `json:"ipv4,omitempty"`       // IPv4 address or Auto
+	IPv6       string `json:"ipv6,omitempty"`       // IPv6 address or Auto
+	IPv6Prefix string `json:"ipv6prefix,omitempty"` // IPv6 prefix in CIDR notation or Auto
+}
+
+func (a *Addrs) empty() bool {
+	return a.IPv4 == `` && a.IPv6 == `` && a.IPv6Prefix == ``
+}
+
+// Client updates hosts through the dynv6 update API.
+type Client struct {
+	//# HTTP Token
+	//
+	// You can get it at https://dynv6.com/keys
+	Token string `json:"token,omitempty"`
+
+	Endpoint   string       `json:"endpoint,omitempty"` // defaults to Endpoint
+	HTTPClient *http.Client `json:"-"`                  // defaults to http.DefaultClient
+}
+
+// Update publishes a for hostname (a dynv6 zone). It reports whether
+// dynv6 changed any address.
+func (c *Client) Update(ctx context.Context, hostname string, a Addrs) (bool, error) {
+	if a.empty() {
+		return false, ErrNoAddress
+	}
+	if c.Token == `` {
+		return false, ErrNoToken
+	}
+
+	q := url.Values{}
+	q.Set(`hostname`, strings.TrimSuffix(hostname, `.`))
+	q.Set(`token`, c.Token)
+	if a.IPv4 != `` {
+		q.Set(`ipv4`, a.IPv4)
+	}
+	if a.IPv6 != `` {
+		q.Set(`ipv6`, a.IPv6)
+	}
+	if a.IPv6Prefix != `` {
+		q.Set(`ipv6prefix`, a.IPv6Prefix)
+	}
+
+	u := c.Endpoint
+	if u == `` {
+		u = Endpoint
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u+`?`+q.Encode(), nil)
+	if err != nil {
+		return false, err
+	}
+	body, err := do(c.HTTPClient, req)
+	if err != nil {
+		return false, err
+	}
+
+	if dynv6.Debug {
+		dynv6.DbgLog.Println(`[Dynv6-debug/update]`, hostname, body)
+	}
+	// "addresses updated" or "addresses unchanged"
+	return !strings.Contains(body, `unchanged`), nil
+}
+
+// do sends req and returns the trimmed response body, or an error for
+// non-2xx responses. Errors never contain the token of the query.
+func do(h *http.Client, req *http.Request) (string, error) {
+	if h == nil {
+		h = http.DefaultClient
+	}
+	resp, err := h.Do(req)
+	if err != nil {
+		var e *url.Error
+		if errors.As(err, &e) {
+			err = &url.Error{Op: e.Op, URL: redactURL(e.URL), Err: e.Err}
+		}
+		return ``, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return ``, err
+	}
+	body := strings.TrimSpace(string(b))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return ``, fmt.Errorf(`update failed: %s: %s`, resp.Status, body)
+	}
+	return body, nil
+}
+
+// redactURL returns the URL s without its token.
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return `<invalid URL>`
+	}
+	q := u.Query()
+	if q.Has(`token`) {
+		q.Set(`token`, `REDACTED`)
+		u.RawQuery = q.Encode()
+	}
+	u.User = nil
+	return u.String()
+}
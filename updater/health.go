@@ -0,0 +1,268 @@
+package updater
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ZxwyProject/libdynv6"
+	"github.com/ZxwyProject/libdynv6/update"
+)
+
+// Status is the outcome of the update cycles so far, see [Updater.Status].
+type Status struct {
+	Started     time.Time `json:"started"`              // first cycle
+	LastAttempt time.Time `json:"last_attempt"`         // last finished cycle
+	LastSuccess time.Time `json:"last_success"`         // last cycle without errors
+	LastError   string    `json:"last_error,omitempty"` // of the last failed cycle, tokens redacted
+	Failures    int       `json:"failures"`             // consecutive failed cycles
+	Cycles      uint64    `json:"cycles"`
+	Errors      uint64    `json:"errors"`
+
+	// IPv4 is behind carrier-grade NAT or DS-Lite, so only IPv6 is pushed.
+	SharedIPv4 bool `json:"shared_ipv4"`
+
+	// Addresses detected in the last cycle, empty if not detected.
+	IPv4 string `json:"ipv4,omitempty"`
+	IPv6 string `json:"ipv6,omitempty"`
+
+	LastPush time.Time `json:"last_push"` // last push of changed addresses to any target
+	Pushes   uint64    `json:"pushes"`
+	NextRun  time.Time `json:"next_run"` // next cycle scheduled by Run
+
+	// Last pushed state by zone, as kept in StateFile.
+	Zones map[string]State `json:"zones,omitempty"`
+}
+
+// observe records the outcome of a cycle.
+func (u *Updater) observe(start time.Time, err error) {
+	var msg string
+	if err != nil {
+		msg = u.redact(err.Error())
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	h := &u.h
+	if h.Started.IsZero() {
+		h.Started = start
+	}
+	h.LastAttempt = time.Now()
+	h.Cycles++
+	if err != nil {
+		h.Errors++
+		h.Failures++
+		h.LastError = msg
+		return
+	}
+	h.Failures = 0
+	h.LastSuccess = h.LastAttempt
+}
+
+// Credentials in the URLs of failed requests.
+var (
+	urlToken    = regexp.MustCompile(`([?&](?i:token|password|key)=)[^&\s"']+`)
+	urlUserinfo = regexp.MustCompile(`(://)[^/@\s"']+@`)
+)
+
+// redact replaces the tokens of Provider and credentials in URLs within s
+// by [libdynv6.Redacted], since Status is served by Handler.
+func (u *Updater) redact(s string) string {
+	s = urlToken.ReplaceAllString(s, `${1}`+libdynv6.Redacted)
+	s = urlUserinfo.ReplaceAllString(s, `${1}`+libdynv6.Redacted+`@`)
+	if u.Provider == nil {
+		return s
+	}
+	toks := make([]string, 0, 1+len(u.Provider.ZoneTokens))
+	if t, err := u.Provider.ResolveToken(); err == nil {
+		toks = append(toks, t)
+	}
+	for _, t := range u.Provider.ZoneTokens {
+		toks = append(toks, libdynv6.ExpandEnv(t))
+	}
+	for _, t := range toks {
+		if t != `` {
+			s = strings.ReplaceAll(s, t, libdynv6.Redacted)
+		}
+	}
+	return s
+}
+
+// detected records the addresses of a cycle, see Status.
+func (u *Updater) detected(a update.Addrs, shared bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.h.IPv4, u.h.IPv6 = a.IPv4, a.IPv6
+	u.h.SharedIPv4 = shared
+}
+
+// pushed records a successful push to a target.
+func (u *Updater) pushed() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.h.LastPush = time.Now()
+	u.h.Pushes++
+}
+
+// scheduled records the time of the next cycle.
+func (u *Updater) scheduled(t time.Time) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.h.NextRun = t
+}
+
+// Status returns the outcome of the update cycles so far, e.g. for a user
+// interface embedding the updater.
+func (u *Updater) Status() Status {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	s := u.h
+	u.load()
+	if len(u.last) > 0 {
+		s.Zones = make(map[string]State, len(u.last))
+		for z, st := range u.last {
+			s.Zones[z] = st
+		}
+	}
+	return s
+}
+
+// Healthy reports whether a cycle succeeded within StaleAfter, or since
+// the first cycle started if it is more recent.
+func (u *Updater) Healthy(now time.Time) bool {
+	s := u.Status()
+	last := s.LastSuccess
+	if last.IsZero() {
+		last = s.Started
+	}
+	return last.IsZero() || now.Sub(last) <= u.staleAfter()
+}
+
+func (u *Updater) staleAfter() time.Duration {
+	if u.StaleAfter > 0 {
+		return u.StaleAfter
+	}
+	i := u.Interval
+	if i <= 0 {
+		i = DefaultInterval
+	}
+	return 3 * i
+}
+
+// Handler returns a handler for supervisors such as Kubernetes or systemd:
+//
+//	/healthz  200 if [Updater.Healthy], else 503
+//	/readyz   200 once a cycle succeeded, else 503
+//	/metrics  the Status and the API latency of Provider in the
+//	          Prometheus text format
+//
+// The Status is reported as JSON by /healthz and /readyz.
+func (u *Updater) Handler() http.Handler {
+	m := http.NewServeMux()
+	m.HandleFunc(`/healthz`, func(w http.ResponseWriter, r *http.Request) {
+		u.probe(w, u.Healthy(time.Now()))
+	})
+	m.HandleFunc(`/readyz`, func(w http.ResponseWriter, r *http.Request) {
+		u.probe(w, !u.Status().LastSuccess.IsZero())
+	})
+	m.HandleFunc(`/metrics`, u.metrics)
+	return m
+}
+
+func (u *Updater) probe(w http.ResponseWriter, ok bool) {
+	s := u.Status()
+	w.Header().Set(`Content-Type`, `application/json`)
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(struct {
+		OK bool `json:"ok"`
+		Status
+	}{ok, s})
+}
+
+func (u *Updater) metrics(w http.ResponseWriter, r *http.Request) {
+	s := u.Status()
+	var b strings.Builder
+	metric := func(name, typ, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+	metric(`dynv6_updater_last_success_timestamp_seconds`, `gauge`, `Time of the last successful update cycle.`)
+	fmt.Fprintf(&b, "dynv6_updater_last_success_timestamp_seconds %s\n", unixTime(s.LastSuccess))
+	metric(`dynv6_updater_last_attempt_timestamp_seconds`, `gauge`, `Time of the last update cycle.`)
+	fmt.Fprintf(&b, "dynv6_updater_last_attempt_timestamp_seconds %s\n", unixTime(s.LastAttempt))
+	metric(`dynv6_updater_consecutive_failures`, `gauge`, `Update cycles failed since the last success.`)
+	fmt.Fprintf(&b, "dynv6_updater_consecutive_failures %d\n", s.Failures)
+	metric(`dynv6_updater_shared_ipv4`, `gauge`, `1 if IPv4 is behind carrier-grade NAT or DS-Lite and only IPv6 is pushed.`)
+	shared := 0
+	if s.SharedIPv4 {
+		shared = 1
+	}
+	fmt.Fprintf(&b, "dynv6_updater_shared_ipv4 %d\n", shared)
+	metric(`dynv6_updater_last_push_timestamp_seconds`, `gauge`, `Time of the last push of changed addresses.`)
+	fmt.Fprintf(&b, "dynv6_updater_last_push_timestamp_seconds %s\n", unixTime(s.LastPush))
+	metric(`dynv6_updater_next_run_timestamp_seconds`, `gauge`, `Time of the next scheduled update cycle.`)
+	fmt.Fprintf(&b, "dynv6_updater_next_run_timestamp_seconds %s\n", unixTime(s.NextRun))
+	metric(`dynv6_updater_pushes_total`, `counter`, `Pushes of changed addresses to targets.`)
+	fmt.Fprintf(&b, "dynv6_updater_pushes_total %d\n", s.Pushes)
+	if len(s.Zones) > 0 {
+		zones := make([]string, 0, len(s.Zones))
+		for z := range s.Zones {
+			zones = append(zones, z)
+		}
+		sort.Strings(zones)
+		metric(`dynv6_updater_zone_last_push_timestamp_seconds`, `gauge`, `Time of the last push by zone.`)
+		for _, z := range zones {
+			fmt.Fprintf(&b, "dynv6_updater_zone_last_push_timestamp_seconds{zone=%q} %s\n", z, unixTime(s.Zones[z].Time))
+		}
+	}
+	metric(`dynv6_updater_address_info`, `gauge`, `Addresses detected in the last cycle.`)
+	for _, x := range []struct{ f, a string }{{`ipv4`, s.IPv4}, {`ipv6`, s.IPv6}} {
+		if x.a != `` {
+			fmt.Fprintf(&b, "dynv6_updater_address_info{family=%q,address=%q} 1\n", x.f, x.a)
+		}
+	}
+	metric(`dynv6_updater_cycles_total`, `counter`, `Update cycles by result.`)
+	fmt.Fprintf(&b, "dynv6_updater_cycles_total{result=\"ok\"} %d\n", s.Cycles-s.Errors)
+	fmt.Fprintf(&b, "dynv6_updater_cycles_total{result=\"error\"} %d\n", s.Errors)
+
+	if u.Provider != nil {
+		st := u.Provider.Stats()
+		ops := make([]string, 0, len(st))
+		for op := range st {
+			ops = append(ops, op)
+		}
+		sort.Strings(ops)
+		metric(`dynv6_api_calls_total`, `counter`, `dynv6 API calls by operation.`)
+		for _, op := range ops {
+			fmt.Fprintf(&b, "dynv6_api_calls_total{op=%q} %d\n", op, st[op].Calls)
+		}
+		metric(`dynv6_api_errors_total`, `counter`, `Failed dynv6 API calls by operation.`)
+		for _, op := range ops {
+			fmt.Fprintf(&b, "dynv6_api_errors_total{op=%q} %d\n", op, st[op].Errors)
+		}
+		metric(`dynv6_api_latency_seconds`, `gauge`, `Latency of recent dynv6 API calls by operation.`)
+		for _, op := range ops {
+			x := st[op]
+			for _, q := range []struct {
+				q string
+				d time.Duration
+			}{{`0.5`, x.P50}, {`0.95`, x.P95}, {`1`, x.Max}} {
+				fmt.Fprintf(&b, "dynv6_api_latency_seconds{op=%q,quantile=%q} %g\n", op, q.q, q.d.Seconds())
+			}
+		}
+	}
+
+	w.Header().Set(`Content-Type`, `text/plain; version=0.0.4; charset=utf-8`)
+	w.Write([]byte(b.String()))
+}
+
+func unixTime(t time.Time) string {
+	if t.IsZero() {
+		return `0`
+	}
+	return fmt.Sprintf(`%.3f`, float64(t.UnixMilli())/1000)
+}
@@ -0,0 +1,68 @@
+package updater
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ZxwyProject/libdynv6"
+)
+
+func TestRedact(t *testing.T) {
+	u := &Updater{Provider: &libdynv6.Provider{
+		Token:      `secret-token`,
+		ZoneTokens: map[string]string{`example.dynv6.net`: `zone-token`},
+	}}
+	for _, c := range []struct{ in, want string }{
+		{`no credentials`, `no credentials`},
+		{`Get "https://dynv6.com/api/update?hostname=x&token=abc": timeout`, `Get "https://dynv6.com/api/update?hostname=x&token=REDACTED": timeout`},
+		{`Get "https://u:p@example.com/nic/update": EOF`, `Get "https://REDACTED@example.com/nic/update": EOF`},
+		{`bearer secret-token rejected`, `bearer REDACTED rejected`},
+		{`zone-token: 401`, `REDACTED: 401`},
+	} {
+		if got := u.redact(c.in); got != c.want {
+			t.Errorf(`redact(%q) = %q, want %q`, c.in, got, c.want)
+		}
+	}
+}
+
+func TestHandlerProbe(t *testing.T) {
+	u := &Updater{Provider: &libdynv6.Provider{Token: `secret-token`}}
+	now := time.Now()
+	h := u.Handler()
+	for _, c := range []struct {
+		err    error
+		path   string
+		code   int
+		ok     bool
+		errors uint64
+	}{
+		{errors.New(`token secret-token rejected`), `/readyz`, http.StatusServiceUnavailable, false, 1},
+		{nil, `/readyz`, http.StatusOK, true, 1},
+		{nil, `/healthz`, http.StatusOK, true, 1},
+	} {
+		u.observe(now, c.err)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(`GET`, c.path, nil))
+		if w.Code != c.code {
+			t.Errorf(`%s after %v: %d, want %d`, c.path, c.err, w.Code, c.code)
+		}
+		if strings.Contains(w.Body.String(), `secret-token`) {
+			t.Errorf(`%s leaks the token: %s`, c.path, w.Body)
+		}
+		var s struct {
+			OK bool `json:"ok"`
+			Status
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &s); err != nil {
+			t.Fatalf(`%s: %v`, c.path, err)
+		}
+		if s.OK != c.ok || s.Errors != c.errors || s.LastError != `token REDACTED rejected` {
+			t.Errorf(`%s: %+v`, c.path, s)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/ZxwyProject/dynv6"
+)
+
+// Event is a change of the addresses published for a target, passed to
+// [Updater.OnChange] and [Hook] commands after the push succeeded (and was
+// verified, see [Verify]).
+type Event struct {
+	Target *Target
+	Old    State // zero if nothing was pushed before, e.g. without StateFile
+	New    State
+}
+
+// Hook is an external command run on each Event, e.g. to restart a tunnel
+// or update firewall rules. It gets the environment of the updater plus
+//
+//	DYNV6_ZONE                        the zone of the target
+//	DYNV6_OLD_IPV4, DYNV6_NEW_IPV4    addresses, empty if unknown
+//	DYNV6_OLD_IPV6, DYNV6_NEW_IPV6
+//	DYNV6_OLD_PREFIX, DYNV6_NEW_PREFIX  prefix of PrefixHosts and Records
+type Hook struct {
+	Exec    []string      `json:"exec"`              // command and arguments
+	Zones   []string      `json:"zones,omitempty"`   // run for these zones only, default all
+	Timeout time.Duration `json:"timeout,omitempty"` // per run, defaults to 30s
+}
+
+// changed runs the OnChange callback and the Hooks for e. Their errors are
+// returned, but the push is not repeated.
+func (u *Updater) changed(ctx context.Context, e *Event) error {
+	var errs []error
+	if u.OnChange != nil {
+		if err := u.OnChange(ctx, *e); err != nil {
+			errs = append(errs, fmt.Errorf(`on change: %v`, err))
+		}
+	}
+	for i := range u.Hooks {
+		if err := u.Hooks[i].run(ctx, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *Hook) run(ctx context.Context, e *Event) error {
+	if len(h.Exec) == 0 || !h.matches(e.Target.Zone) {
+		return nil
+	}
+	t := h.Timeout
+	if t <= 0 {
+		t = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, t)
+	defer cancel()
+	c := exec.CommandContext(ctx, h.Exec[0], h.Exec[1:]...)
+	c.Env = append(os.Environ(),
+		`DYNV6_ZONE=`+e.Target.Zone,
+		`DYNV6_OLD_IPV4=`+e.Old.IPv4,
+		`DYNV6_NEW_IPV4=`+e.New.IPv4,
+		`DYNV6_OLD_IPV6=`+e.Old.IPv6,
+		`DYNV6_NEW_IPV6=`+e.New.IPv6,
+		`DYNV6_OLD_PREFIX=`+e.Old.Prefix,
+		`DYNV6_NEW_PREFIX=`+e.New.Prefix,
+	)
+	out, err := c.CombinedOutput()
+	if dynv6.Debug {
+		dynv6.DbgLog.Println(`[Dynv6-debug/updater] hook`, h.Exec[0], `for`, e.Target.Zone+`:`, strings.TrimSpace(string(out)))
+	}
+	if err != nil {
+		return fmt.Errorf(`hook %s: %v`, h.Exec[0], err)
+	}
+	return nil
+}
+
+func (h *Hook) matches(zone string) bool {
+	if len(h.Zones) == 0 {
+		return true
+	}
+	for _, z := range h.Zones {
+		if z == zone {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package updater
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+// PrefixHost is a host whose IPv6 address is the current delegated prefix
+// combined with a fixed suffix, so it follows prefix changes of the ISP.
+type PrefixHost struct {
+	Name string `json:"name"` // record name within the zone
+
+	// Suffix provides the bits after the prefix, i.e. subnet and interface
+	// identifier, e.g. "::1:0:0:0:10" for host ::10 in subnet 1 of a /56.
+	Suffix string `json:"suffix,omitempty"`
+
+	// MAC of a device using SLAAC without privacy extensions. If set, the
+	// interface identifier (last 64 bits of Suffix) is derived from it.
+	MAC string `json:"mac,omitempty"`
+}
+
+// EUI64 returns the modified EUI-64 interface identifier of mac (RFC 4291)
+// as the last 64 bits of an otherwise zero IPv6 address.
+func EUI64(mac net.HardwareAddr) (netip.Addr, error) {
+	var a [16]byte
+	switch len(mac) {
+	case 6:
+		copy(a[8:11], mac[:3])
+		a[11], a[12] = 0xff, 0xfe
+		copy(a[13:], mac[3:])
+	case 8:
+		copy(a[8:], mac)
+	default:
+		return netip.Addr{}, fmt.Errorf(`invalid MAC %s`, mac)
+	}
+	a[8] ^= 0x02 // universal/local bit
+	return netip.AddrFrom16(a), nil
+}
+
+// Combine returns the address consisting of the first prefix.Bits() bits
+// of prefix and the remaining bits of suffix.
+func Combine(prefix netip.Prefix, suffix netip.Addr) (netip.Addr, error) {
+	if !prefix.Addr().Is6() || !suffix.Is6() {
+		return netip.Addr{}, fmt.Errorf(`combine %s and %s: not IPv6`, prefix, suffix)
+	}
+	p, s := prefix.Masked().Addr().As16(), suffix.As16()
+	n := prefix.Bits()
+	for i := 0; i < 16; i++ {
+		var m byte // prefix mask of byte i
+		switch {
+		case n >= (i+1)*8:
+			m = 0xff
+		case n > i*8:
+			m = ^byte(0xff >> (n - i*8))
+		}
+		p[i] = p[i]&m | s[i]&^m
+	}
+	return netip.AddrFrom16(p), nil
+}
+
+// address returns the current address of h under prefix.
+func (h *PrefixHost) address(prefix netip.Prefix) (netip.Addr, error) {
+	s := netip.IPv6Unspecified()
+	if h.Suffix != `` {
+		var err error
+		if s, err = netip.ParseAddr(h.Suffix); err != nil {
+			return netip.Addr{}, fmt.Errorf(`host %s: %v`, h.Name, err)
+		}
+	}
+	if h.MAC != `` {
+		mac, err := net.ParseMAC(h.MAC)
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf(`host %s: %v`, h.Name, err)
+		}
+		iid, err := EUI64(mac)
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf(`host %s: %v`, h.Name, err)
+		}
+		// subnet from Suffix, interface identifier from MAC
+		if s, err = Combine(netip.PrefixFrom(s, 64), iid); err != nil {
+			return netip.Addr{}, err
+		}
+	}
+	return Combine(prefix, s)
+}
@@ -0,0 +1,95 @@
+package updater
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ZxwyProject/libdynv6/update"
+)
+
+// State is what was last pushed for a zone.
+type State struct {
+	update.Addrs
+	Prefix string    `json:"prefix,omitempty"` // prefix used for PrefixHosts
+	Time   time.Time `json:"time"`
+}
+
+func (s *State) equal(o *State) bool {
+	return s.Addrs == o.Addrs && s.Prefix == o.Prefix
+}
+
+// unchanged reports whether st equals the last pushed state of zone.
+func (u *Updater) unchanged(zone string, st *State) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.load()
+	last, ok := u.last[zone]
+	return ok && last.equal(st)
+}
+
+// previous returns the last pushed state of zone, if any.
+func (u *Updater) previous(zone string) State {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.load()
+	return u.last[zone]
+}
+
+// remember stores st as the last pushed state of zone.
+func (u *Updater) remember(zone string, st *State) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.load()
+	st.Time = time.Now().UTC()
+	u.last[zone] = *st
+	return u.save()
+}
+
+// load reads the state file once. A missing or broken file is ignored,
+// which only causes one extra update.
+func (u *Updater) load() {
+	if u.last != nil {
+		return
+	}
+	u.last = make(map[string]State)
+	if u.StateFile == `` {
+		return
+	}
+	b, err := os.ReadFile(u.StateFile)
+	if err == nil {
+		err = json.Unmarshal(b, &u.last)
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		u.last = make(map[string]State)
+	}
+}
+
+// save writes the state file atomically.
+func (u *Updater) save() error {
+	if u.StateFile == `` {
+		return nil
+	}
+	b, err := json.MarshalIndent(u.last, ``, `  `)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(u.StateFile), `.dynv6-state-*`)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), u.StateFile)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
@@ -0,0 +1,74 @@
+package updater
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ZxwyProject/dynv6"
+)
+
+// sdNotify sends state to the systemd notification socket, if any.
+func sdNotify(state ...string) {
+	addr := os.Getenv(`NOTIFY_SOCKET`)
+	if addr == `` {
+		return
+	}
+	c, err := net.Dial(`unixgram`, addr)
+	if err != nil {
+		if dynv6.Debug {
+			dynv6.DbgLog.Println(`[Dynv6-debug/updater] sd_notify:`, err)
+		}
+		return
+	}
+	defer c.Close()
+	c.Write([]byte(strings.Join(state, "\n")))
+}
+
+// sdWatchdog returns the watchdog interval systemd expects pings at, or 0.
+func sdWatchdog() time.Duration {
+	if os.Getenv(`NOTIFY_SOCKET`) == `` {
+		return 0
+	}
+	if pid := os.Getenv(`WATCHDOG_PID`); pid != `` && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	us, err := strconv.ParseInt(os.Getenv(`WATCHDOG_USEC`), 10, 64)
+	if err != nil || us <= 0 {
+		return 0
+	}
+	return time.Duration(us) * time.Microsecond
+}
+
+// watchdog pings the systemd watchdog while u is healthy, until ctx is done.
+func (u *Updater) watchdog(ctx context.Context) {
+	wd := sdWatchdog()
+	if wd == 0 {
+		return
+	}
+	t := time.NewTicker(wd / 2)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			if u.Healthy(now) {
+				sdNotify(`WATCHDOG=1`)
+			} else if dynv6.Debug {
+				dynv6.DbgLog.Println(`[Dynv6-debug/updater] unhealthy, watchdog not pinged`)
+			}
+		}
+	}
+}
+
+// sdStatus returns the STATUS line for the outcome of a cycle.
+func sdStatus(err error) string {
+	if err != nil {
+		return `STATUS=update failed: ` + strings.ReplaceAll(err.Error(), "\n", ` `)
+	}
+	return `STATUS=updated ` + time.Now().Format(time.RFC3339)
+}
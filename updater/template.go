@@ -0,0 +1,157 @@
+package updater
+
+import (
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ZxwyProject/libdynv6/update"
+	"github.com/libdns/libdns"
+)
+
+// RecordTemplate is a record derived from the detected addresses, so one
+// detection keeps any number of dependent records up to date. Name and
+// Data may contain placeholders:
+//
+//	{ipv4}, {ipv6}    the detected addresses
+//	{prefix}          the prefix of PrefixHosts, e.g. 2001:db8:1::/56
+//	{host:NAME}       the address of the PrefixHost NAME
+//	{reverse:X}       the reverse name of X, one of the above, e.g.
+//	                  1.2.0.192.in-addr.arpa. for {reverse:ipv4}
+//
+// Names ending in a dot, like reverse names, are made relative to the zone,
+// e.g. a reverse zone at dynv6. A template is skipped while a value it uses is unknown, e.g. {ipv4}
+// while only IPv6 is detected, leaving its record untouched.
+type RecordTemplate struct {
+	Name string        `json:"name"`
+	Type string        `json:"type"`
+	Data string        `json:"data"`
+	TTL  time.Duration `json:"ttl,omitempty"`
+}
+
+var placeholder = regexp.MustCompile(`\{([a-z0-9:._-]+)\}`)
+
+// templateValues returns the placeholder values of t, without braces.
+// Unknown values are empty.
+func templateValues(t *Target, a update.Addrs, prefix netip.Prefix) (map[string]string, error) {
+	v := map[string]string{`ipv4`: ``, `ipv6`: ``, `prefix`: ``}
+	if ip, err := netip.ParseAddr(a.IPv4); err == nil {
+		v[`ipv4`] = ip.String()
+	}
+	if ip, err := netip.ParseAddr(a.IPv6); err == nil {
+		v[`ipv6`] = ip.String()
+	}
+	if prefix.IsValid() {
+		v[`prefix`] = prefix.String()
+	}
+	for i := range t.PrefixHosts {
+		h := &t.PrefixHosts[i]
+		v[`host:`+h.Name] = ``
+		if prefix.IsValid() {
+			ip, err := h.address(prefix)
+			if err != nil {
+				return nil, err
+			}
+			v[`host:`+h.Name] = ip.String()
+		}
+	}
+	r := make(map[string]string, 2*len(v))
+	for k, x := range v {
+		r[k] = x
+		r[`reverse:`+k] = reverseName(x)
+	}
+	return r, nil
+}
+
+// expand replaces the placeholders in s. ok is false if a value is unknown.
+func expand(s string, v map[string]string) (_ string, ok bool, err error) {
+	ok = true
+	o := placeholder.ReplaceAllStringFunc(s, func(m string) string {
+		x, known := v[m[1:len(m)-1]]
+		switch {
+		case !known:
+			if err == nil {
+				err = fmt.Errorf(`unknown placeholder %s`, m)
+			}
+		case x == ``:
+			ok = false
+		}
+		return x
+	})
+	return o, ok, err
+}
+
+// templateRecords returns the records of the templates of t which can be
+// expanded.
+func templateRecords(t *Target, a update.Addrs, prefix netip.Prefix) ([]libdns.Record, error) {
+	if len(t.Records) == 0 {
+		return nil, nil
+	}
+	v, err := templateValues(t, a, prefix)
+	if err != nil {
+		return nil, err
+	}
+	var r []libdns.Record
+	for _, x := range t.Records {
+		name, ok1, err := expand(x.Name, v)
+		if err != nil {
+			return nil, fmt.Errorf(`record %s: %v`, x.Name, err)
+		}
+		data, ok2, err := expand(x.Data, v)
+		if err != nil {
+			return nil, fmt.Errorf(`record %s: %v`, x.Name, err)
+		}
+		if strings.HasSuffix(name, `.`) {
+			name = libdns.RelativeName(name, strings.TrimSuffix(t.Zone, `.`)+`.`)
+		}
+		if ok1 && ok2 {
+			r = append(r, libdns.RR{Name: name, Type: x.Type, Data: data, TTL: x.TTL})
+		}
+	}
+	return r, nil
+}
+
+// reverseName returns the in-addr.arpa. or ip6.arpa. name of an address
+// or, for prefixes of whole nibbles or octets, of a network, or "".
+func reverseName(s string) string {
+	p, err := netip.ParsePrefix(s)
+	if err != nil {
+		a, err := netip.ParseAddr(s)
+		if err != nil {
+			return ``
+		}
+		p = netip.PrefixFrom(a, a.BitLen())
+	}
+	a, bits := p.Masked().Addr(), p.Bits()
+	var b strings.Builder
+	if a.Is4() {
+		if bits%8 != 0 {
+			return ``
+		}
+		o := a.As4()
+		for i := bits/8 - 1; i >= 0; i-- {
+			b.WriteString(strconv.Itoa(int(o[i])))
+			b.WriteByte('.')
+		}
+		b.WriteString(`in-addr.arpa.`)
+		return b.String()
+	}
+	if bits%4 != 0 {
+		return ``
+	}
+	const hex = `0123456789abcdef`
+	o := a.As16()
+	for i := bits/4 - 1; i >= 0; i-- {
+		n := o[i/2]
+		if i%2 == 0 {
+			n >>= 4
+		}
+		b.WriteByte(hex[n&0xf])
+		b.WriteByte('.')
+	}
+	b.WriteString(`ip6.arpa.`)
+	return b.String()
+}
@@ -0,0 +1,523 @@
+// Package updater keeps dynv6 zones and host records in sync with the
+// current public addresses, for embedding in small dynamic DNS daemons.
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/ZxwyProject/dynv6"
+	"github.com/ZxwyProject/libdynv6"
+	"github.com/ZxwyProject/libdynv6/ipdetect"
+	"github.com/ZxwyProject/libdynv6/update"
+	"github.com/libdns/libdns"
+)
+
+// Address family modes.
+const (
+	ModeDual = `dual` // IPv4 and IPv6
+	ModeIPv4 = `ipv4` // IPv4 only, IPv6 entries are left untouched
+	ModeIPv6 = `ipv6` // IPv6 only, IPv4 entries are left untouched
+)
+
+// Defaults used if not configured.
+const (
+	DefaultInterval    = 5 * time.Minute
+	DefaultConcurrency = 4
+)
+
+// Target is a zone to keep up to date.
+type Target struct {
+	Zone string `json:"zone"`
+
+	// Names of A/AAAA records within the zone ("@" for the apex), updated
+	// through the REST API. If empty, the zone addresses themselves are
+	// updated through the update Backend.
+	Hosts []string `json:"hosts,omitempty"`
+
+	// AAAA records derived from the delegated prefix, updated through the
+	// REST API in the same pass as Hosts.
+	PrefixHosts []PrefixHost `json:"prefix_hosts,omitempty"`
+	// Use the IPv6 prefix of the zone for PrefixHosts instead of the
+	// detected one, e.g. if the zone prefix is updated by the router.
+	ZonePrefix bool `json:"zone_prefix,omitempty"`
+
+	// Records derived from the same detection, e.g. TXT or PTR records,
+	// updated through the REST API in the same pass as Hosts.
+	Records []RecordTemplate `json:"records,omitempty"`
+
+	// With Hosts, PrefixHosts or Records, also update the zone addresses
+	// and, unless ZonePrefix is set, the IPv6 prefix of the zone through
+	// the update Backend.
+	ZoneAddrs bool `json:"zone_addrs,omitempty"`
+}
+
+// zoneOnly reports whether t updates the zone addresses only.
+func (t *Target) zoneOnly() bool {
+	return len(t.Hosts) == 0 && len(t.PrefixHosts) == 0 && len(t.Records) == 0
+}
+
+// Backoff is the retry policy after a failed cycle. The delay starts at Min
+// and is multiplied by Factor after each consecutive failure, up to Max,
+// and is shortened by a random fraction of up to Jitter.
+type Backoff struct {
+	Min    time.Duration `json:"min,omitempty"`    // defaults to 30s
+	Max    time.Duration `json:"max,omitempty"`    // defaults to Interval
+	Factor float64       `json:"factor,omitempty"` // defaults to 2
+
+	// Spreads retries, so that many updaters do not hit the API in
+	// lockstep once an outage ends. Defaults to 0.2, negative disables.
+	Jitter float64 `json:"jitter,omitempty"`
+
+	// Delay of the cycle after the first success following failures, to
+	// catch addresses which changed again as the connection came back,
+	// e.g. on a PPPoE reconnect. Defaults to Min, negative waits Interval.
+	Recover time.Duration `json:"recover,omitempty"`
+}
+
+// Updater periodically detects the public addresses and publishes them.
+type Updater struct {
+	// Provider updates host records. Its token is also used for the
+	// default Backend.
+	Provider *libdynv6.Provider `json:"-"`
+	// Backend updates zone addresses, defaults to the dynv6 update API.
+	Backend update.Backend `json:"-"`
+	// Detector finds the public addresses, defaults to [ipdetect.Default].
+	Detector ipdetect.Detector `json:"-"`
+	// Source provides the addresses instead of Detector if set, e.g. from
+	// the router, and the prefix for PrefixHosts unless ZonePrefix is set.
+	Source ipdetect.AddressSource `json:"-"`
+
+	Targets  []Target      `json:"targets"`
+	Interval time.Duration `json:"interval,omitempty"` // defaults to DefaultInterval
+	Backoff  Backoff       `json:"backoff,omitempty"`
+
+	// Address families to detect and push: ModeDual (default), ModeIPv4 or
+	// ModeIPv6. Families not pushed are never cleared or overwritten.
+	Mode string `json:"mode,omitempty"`
+
+	// Maximum number of targets updated in parallel, defaults to DefaultConcurrency.
+	Concurrency int `json:"concurrency,omitempty"`
+
+	// Length of the delegated IPv6 prefix for PrefixHosts, derived from the
+	// detected IPv6 address. Defaults to 64.
+	PrefixLen int `json:"prefix_len,omitempty"`
+
+	// File to persist the last pushed state across restarts. Targets whose
+	// addresses did not change since the last push are always skipped, to
+	// respect dynv6's limits on no-op updates.
+	StateFile string `json:"state_file,omitempty"`
+
+	// Checks that pushed addresses are live, see [Verify].
+	Verify Verify `json:"verify,omitempty"`
+
+	// Delete the A records of Hosts while the IPv4 address is behind
+	// carrier-grade NAT or DS-Lite (see [ipdetect.ErrShared]), since they
+	// point to an unreachable address. Only IPv6 is pushed then anyway.
+	RemoveSharedA bool `json:"remove_shared_a,omitempty"`
+
+	// Run a cycle as soon as local interface addresses change, besides
+	// every Interval, see [ipdetect.WatchAddrs].
+	WatchAddrs bool `json:"watch_addrs,omitempty"`
+
+	// Called after new addresses were published for a target, see Event.
+	OnChange func(ctx context.Context, e Event) error `json:"-"`
+	// Commands run after new addresses were published, see Hook.
+	Hooks []Hook `json:"hooks,omitempty"`
+
+	// Time without a successful cycle after which [Updater.Healthy]
+	// reports false. Defaults to 3 Intervals.
+	StaleAfter time.Duration `json:"stale_after,omitempty"`
+
+	mu   sync.Mutex
+	last map[string]State // by zone
+	h    Status
+}
+
+// watchSettle is the delay between an address change and the cycle it
+// triggers, so duplicate address detection can finish and bursts of
+// changes are handled at once.
+const watchSettle = 2 * time.Second
+
+// Run updates all targets every Interval until ctx is done, retrying
+// failed cycles according to Backoff; the first success after failures is
+// followed up by a cycle after Backoff.Recover. With WatchAddrs, address
+// changes trigger a cycle early. It returns ctx.Err(), so a service
+// should cancel ctx on SIGTERM, e.g. with [os/signal.NotifyContext];
+// requests in flight are aborted then.
+//
+// Started by systemd with NOTIFY_SOCKET set, e.g. as Type=notify service,
+// Run reports READY=1 when started, the outcome of each cycle as
+// STATUS and STOPPING=1 once ctx is done. With WatchdogSec= set, it pings
+// the watchdog at half its interval while [Updater.Healthy], so systemd
+// restarts an updater that hangs or keeps failing beyond StaleAfter.
+func (u *Updater) Run(ctx context.Context) error {
+	interval := u.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	fails := 0
+	var changed <-chan struct{}
+	if u.WatchAddrs {
+		changed = ipdetect.WatchAddrs(ctx)
+	}
+
+	// Ready before the first cycle, which may take longer than systemd
+	// waits for startup.
+	sdNotify(`READY=1`, `STATUS=starting`)
+	defer sdNotify(`STOPPING=1`)
+	go u.watchdog(ctx)
+
+	for {
+		wait := interval
+		err := u.Once(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		sdNotify(sdStatus(err))
+		if err != nil {
+			fails++
+			wait = u.backoff(fails, interval)
+			// Never earlier than dynv6 asked for.
+			var rl *libdynv6.RateLimitError
+			if errors.As(err, &rl) && rl.RateLimit.RetryAfter > wait {
+				wait = rl.RateLimit.RetryAfter
+			}
+			if dynv6.Debug {
+				dynv6.DbgLog.Println(`[Dynv6-debug/updater]`, err, `- retrying in`, wait)
+			}
+		} else {
+			if fails > 0 {
+				wait = u.recoverDelay(interval)
+			}
+			fails = 0
+		}
+
+		t := time.NewTimer(wait)
+		u.scheduled(time.Now().Add(wait))
+	sleep:
+		for {
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return ctx.Err()
+			case <-changed:
+				if dynv6.Debug {
+					dynv6.DbgLog.Println(`[Dynv6-debug/updater] addresses changed`)
+				}
+				t.Stop()
+				t = time.NewTimer(watchSettle)
+				u.scheduled(time.Now().Add(watchSettle))
+			case <-t.C:
+				break sleep
+			}
+		}
+	}
+}
+
+// backoff returns the delay after n consecutive failures.
+func (u *Updater) backoff(n int, interval time.Duration) time.Duration {
+	b := u.Backoff
+	if b.Min <= 0 {
+		b.Min = 30 * time.Second
+	}
+	if b.Max <= 0 {
+		b.Max = interval
+	}
+	if b.Factor < 1 {
+		b.Factor = 2
+	}
+	d := float64(b.Min)
+	for i := 1; i < n && d < float64(b.Max); i++ {
+		d *= b.Factor
+	}
+	if d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	switch {
+	case b.Jitter == 0:
+		b.Jitter = 0.2
+	case b.Jitter > 1:
+		b.Jitter = 1
+	}
+	if b.Jitter > 0 {
+		d -= d * b.Jitter * rand.Float64()
+	}
+	return time.Duration(d)
+}
+
+// recoverDelay returns the delay after the first success following failures.
+func (u *Updater) recoverDelay(interval time.Duration) time.Duration {
+	d := u.Backoff.Recover
+	if d == 0 {
+		d = u.Backoff.Min
+		if d <= 0 {
+			d = 30 * time.Second
+		}
+	}
+	if d < 0 || d > interval {
+		return interval
+	}
+	return d
+}
+
+// Once detects the addresses once and pushes them to all targets,
+// at most Concurrency at a time, or fewer if limited by [libdynv6.WithConcurrency].
+// The outcome is recorded in [Updater.Status].
+func (u *Updater) Once(ctx context.Context) (err error) {
+	start := time.Now()
+	defer func() {
+		if ctx.Err() == nil {
+			u.observe(start, err)
+		}
+	}()
+	fs, err := u.families()
+	if err != nil {
+		return err
+	}
+	a, shared, err := u.detect(ctx, fs)
+	if err != nil {
+		return err
+	}
+	n := u.Concurrency
+	if n <= 0 {
+		n = DefaultConcurrency
+	}
+	if c := libdynv6.Concurrency(ctx); c > 0 && c < n {
+		n = c
+	}
+	sem := make(chan struct{}, n)
+	errs := make([]error, len(u.Targets))
+
+	var wg sync.WaitGroup
+	for i := range u.Targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() { <-sem; wg.Done() }()
+			t := &u.Targets[i]
+			if err := u.target(ctx, t, a, shared); err != nil {
+				errs[i] = fmt.Errorf(`%s: %w`, t.Zone, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// detect detects the addresses of the families fs. It fails only if none
+// could be detected. shared reports an IPv4 address behind carrier-grade
+// NAT or DS-Lite, in which case only IPv6 is used.
+func (u *Updater) detect(ctx context.Context, fs []ipdetect.Family) (update.Addrs, bool, error) {
+	var (
+		a      update.Addrs
+		shared bool
+		errs   []error
+	)
+	for _, f := range fs {
+		var x update.Addrs
+		var err error
+		if u.Source != nil {
+			x, err = ipdetect.SourceAddrs(ctx, u.Source, f)
+		} else {
+			x, err = ipdetect.Addrs(ctx, u.Detector, f)
+		}
+		if err != nil {
+			if f == ipdetect.IPv4 && errors.Is(err, ipdetect.ErrShared) {
+				shared = true
+			}
+			errs = append(errs, err)
+			continue
+		}
+		if f == ipdetect.IPv4 {
+			a.IPv4 = x.IPv4
+		} else {
+			a.IPv6 = x.IPv6
+		}
+	}
+	u.detected(a, shared)
+	if shared && dynv6.Debug {
+		dynv6.DbgLog.Println(`[Dynv6-debug/updater] IPv4 is behind carrier-grade NAT or DS-Lite, updating IPv6 only`)
+	}
+	if len(errs) == len(fs) {
+		return a, shared, errors.Join(errs...)
+	}
+	return a, shared, nil
+}
+
+// families returns the address families of Mode.
+func (u *Updater) families() ([]ipdetect.Family, error) {
+	switch u.Mode {
+	case ``, ModeDual:
+		return []ipdetect.Family{ipdetect.IPv4, ipdetect.IPv6}, nil
+	case ModeIPv4:
+		return []ipdetect.Family{ipdetect.IPv4}, nil
+	case ModeIPv6:
+		return []ipdetect.Family{ipdetect.IPv6}, nil
+	default:
+		return nil, fmt.Errorf(`unknown mode %q`, u.Mode)
+	}
+}
+
+// target updates t unless nothing changed since the last push.
+func (u *Updater) target(ctx context.Context, t *Target, a update.Addrs, shared bool) error {
+	st := State{Addrs: a}
+	var prefix netip.Prefix
+	if len(t.PrefixHosts) > 0 || len(t.Records) > 0 || t.ZoneAddrs && !t.ZonePrefix {
+		var err error
+		switch {
+		case t.ZonePrefix:
+			prefix, err = u.zonePrefix(ctx, t.Zone)
+		case u.Source != nil:
+			prefix, err = u.Source.CurrentPrefix(ctx)
+		default:
+			prefix, err = u.prefix(a)
+		}
+		// Only PrefixHosts require a prefix; templates using it are
+		// skipped without.
+		if err != nil && len(t.PrefixHosts) > 0 {
+			return err
+		}
+		if err == nil {
+			st.Prefix = prefix.String()
+		}
+	}
+
+	if u.unchanged(t.Zone, &st) {
+		if dynv6.Debug {
+			dynv6.DbgLog.Println(`[Dynv6-debug/updater]`, t.Zone, `unchanged`)
+		}
+		return nil
+	}
+	if err := u.push(ctx, t, a, prefix, shared); err != nil {
+		return err
+	}
+	u.pushed()
+	// Not remembered if unverified, so the next cycle pushes again.
+	if err := u.verify(ctx, t, a, prefix); err != nil {
+		return err
+	}
+	old := u.previous(t.Zone)
+	if err := u.remember(t.Zone, &st); err != nil {
+		return err
+	}
+	return u.changed(ctx, &Event{Target: t, Old: old, New: st})
+}
+
+// push publishes a and prefix for one target. SetRecords diffs all records
+// against the zone, so only changed ones are written. With shared set and
+// RemoveSharedA, the A records of the hosts are deleted.
+func (u *Updater) push(ctx context.Context, t *Target, a update.Addrs, prefix netip.Prefix, shared bool) error {
+	if t.zoneOnly() || t.ZoneAddrs {
+		b, err := u.backend()
+		if err != nil {
+			return err
+		}
+		za := a
+		if !t.zoneOnly() && !t.ZonePrefix && prefix.IsValid() {
+			za.IPv6Prefix = prefix.String()
+		}
+		if _, err := b.Update(ctx, t.Zone, za); err != nil || t.zoneOnly() {
+			return err
+		}
+	}
+
+	if u.Provider == nil {
+		return errors.New(`no provider configured for host records`)
+	}
+	r, err := hostRecords(t, a, prefix)
+	if err != nil {
+		return err
+	}
+	tr, err := templateRecords(t, a, prefix)
+	if err != nil {
+		return err
+	}
+	r = append(r, tr...)
+	if shared && u.RemoveSharedA && len(t.Hosts) > 0 {
+		d := make([]libdns.Record, len(t.Hosts))
+		for i, h := range t.Hosts {
+			d[i] = libdns.RR{Name: h, Type: `A`}
+		}
+		if _, err := u.Provider.DeleteRecords(ctx, t.Zone, d); err != nil {
+			return err
+		}
+	}
+	if len(r) == 0 {
+		return nil
+	}
+	_, err = u.Provider.SetRecords(ctx, t.Zone, r)
+	return err
+}
+
+// backend returns the Backend, or the update API client for the token of
+// the Provider.
+func (u *Updater) backend() (update.Backend, error) {
+	if u.Backend != nil {
+		return u.Backend, nil
+	}
+	if u.Provider == nil {
+		return nil, errors.New(`no update backend configured`)
+	}
+	tok, err := u.Provider.ResolveToken()
+	if err != nil {
+		return nil, err
+	}
+	return &update.Client{Token: tok}, nil
+}
+
+// hostRecords returns the address records of t for a and prefix. Targets
+// without hosts have the zone addresses at the apex.
+func hostRecords(t *Target, a update.Addrs, prefix netip.Prefix) ([]libdns.Record, error) {
+	hosts := t.Hosts
+	if t.zoneOnly() {
+		hosts = []string{`@`}
+	}
+	var r []libdns.Record
+	for _, h := range hosts {
+		for _, s := range []string{a.IPv4, a.IPv6} {
+			if ip, err := netip.ParseAddr(s); err == nil {
+				r = append(r, libdns.Address{Name: h, IP: ip})
+			}
+		}
+	}
+	for i := range t.PrefixHosts {
+		ip, err := t.PrefixHosts[i].address(prefix)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, libdns.Address{Name: t.PrefixHosts[i].Name, IP: ip})
+	}
+	return r, nil
+}
+
+// prefix returns the delegated prefix of the detected IPv6 address.
+func (u *Updater) prefix(a update.Addrs) (netip.Prefix, error) {
+	ip, err := netip.ParseAddr(a.IPv6)
+	if err != nil {
+		return netip.Prefix{}, errors.New(`no IPv6 address detected for prefix hosts`)
+	}
+	n := u.PrefixLen
+	if n == 0 {
+		n = 64
+	}
+	return ip.Prefix(n)
+}
+
+// zonePrefix returns the IPv6 prefix configured for zone at dynv6.
+func (u *Updater) zonePrefix(ctx context.Context, zone string) (netip.Prefix, error) {
+	z, err := u.Provider.GetZone(ctx, zone)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	p, err := netip.ParsePrefix(z.IPv6Prefix)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf(`zone IPv6 prefix %q: %v`, z.IPv6Prefix, err)
+	}
+	return p, nil
+}
@@ -0,0 +1,231 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"net/netip"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ZxwyProject/libdynv6/dynv6test"
+	"github.com/ZxwyProject/libdynv6/ipdetect"
+	"github.com/ZxwyProject/libdynv6/update"
+)
+
+// detector returns fixed addresses by family, or ErrNotFound.
+type detector map[ipdetect.Family]string
+
+func (d detector) Detect(_ context.Context, f ipdetect.Family) (netip.Addr, error) {
+	if s, ok := d[f]; ok {
+		return netip.ParseAddr(s)
+	}
+	return netip.Addr{}, ipdetect.ErrNotFound
+}
+
+// backend records the updates of zones and fails with err.
+type backend struct {
+	updates []string
+	err     error
+}
+
+func (b *backend) Update(_ context.Context, hostname string, a update.Addrs) (bool, error) {
+	if b.err != nil {
+		return false, b.err
+	}
+	b.updates = append(b.updates, hostname+` `+a.IPv4+` `+a.IPv6+` `+a.IPv6Prefix)
+	return true, nil
+}
+
+func TestOnceZone(t *testing.T) {
+	ctx := context.Background()
+	file := filepath.Join(t.TempDir(), `state.json`)
+	d := detector{ipdetect.IPv4: `203.0.113.1`, ipdetect.IPv6: `2001:db8::1`}
+	b := new(backend)
+	u := &Updater{Detector: d, Backend: b, StateFile: file, Targets: []Target{{Zone: `example.dynv6.net`}}}
+
+	for _, c := range []struct {
+		ipv4    string
+		updates int
+	}{
+		{`203.0.113.1`, 1},
+		{`203.0.113.1`, 1}, // unchanged
+		{`203.0.113.2`, 2},
+	} {
+		d[ipdetect.IPv4] = c.ipv4
+		if err := u.Once(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if len(b.updates) != c.updates {
+			t.Errorf(`%s: updates %q, want %d`, c.ipv4, b.updates, c.updates)
+		}
+	}
+	if want := `example.dynv6.net 203.0.113.2 2001:db8::1 `; b.updates[len(b.updates)-1] != want {
+		t.Errorf(`update %q, want %q`, b.updates[len(b.updates)-1], want)
+	}
+	s := u.Status()
+	if s.Cycles != 3 || s.Pushes != 2 || s.Failures != 0 || s.IPv4 != `203.0.113.2` || s.Zones[`example.dynv6.net`].IPv4 != `203.0.113.2` {
+		t.Errorf(`status %+v`, s)
+	}
+
+	// The state survives a restart.
+	u = &Updater{Detector: d, Backend: b, StateFile: file, Targets: u.Targets}
+	if err := u.Once(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(b.updates) != 2 {
+		t.Errorf(`updates after restart %q`, b.updates)
+	}
+}
+
+func TestOnceHosts(t *testing.T) {
+	const zone = `example.dynv6.net`
+	m := dynv6test.NewMemory(zone)
+	d := detector{ipdetect.IPv4: `203.0.113.1`, ipdetect.IPv6: `2001:db8:0:1::1`}
+	b := new(backend)
+	u := &Updater{
+		Provider: m.Provider,
+		Detector: d,
+		Backend:  b,
+		Mode:     ModeDual,
+		Targets: []Target{{
+			Zone:        zone,
+			Hosts:       []string{`www`},
+			PrefixHosts: []PrefixHost{{Name: `nas`, Suffix: `::10`}},
+			ZoneAddrs:   true,
+		}},
+	}
+	if err := u.Once(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range m.Records(zone) {
+		got = append(got, r.Type+` `+r.Name+` `+r.Data)
+	}
+	sort.Strings(got)
+	want := []string{`A www 203.0.113.1`, `AAAA nas 2001:db8:0:1::10`, `AAAA www 2001:db8:0:1::1`}
+	if strings.Join(got, `,`) != strings.Join(want, `,`) {
+		t.Errorf("records\n%q\nwant\n%q", got, want)
+	}
+	if want := zone + ` 203.0.113.1 2001:db8:0:1::1 2001:db8:0:1::/64`; len(b.updates) != 1 || b.updates[0] != want {
+		t.Errorf(`updates %q, want %q`, b.updates, want)
+	}
+}
+
+func TestOnceFailure(t *testing.T) {
+	ctx := context.Background()
+	for _, c := range []struct {
+		name string
+		u    *Updater
+		err  string
+	}{
+		{`mode`, &Updater{Mode: `ipv5`}, `unknown mode`},
+		{`detect`, &Updater{Detector: detector{}, Backend: new(backend)}, `no address found`},
+		{`backend`, &Updater{Detector: detector{ipdetect.IPv4: `203.0.113.1`}, Backend: &backend{err: errors.New(`badauth`)}}, `badauth`},
+		{`provider`, &Updater{Detector: detector{ipdetect.IPv4: `203.0.113.1`}}, `no update backend`},
+	} {
+		c.u.Targets = []Target{{Zone: `example.dynv6.net`}}
+		for i := 1; i <= 2; i++ {
+			err := c.u.Once(ctx)
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf(`%s: %v, want %s`, c.name, err, c.err)
+			}
+			s := c.u.Status()
+			if s.Failures != i || s.Errors != uint64(i) || !s.LastSuccess.IsZero() || !strings.Contains(s.LastError, c.err) {
+				t.Errorf(`%s: status %+v`, c.name, s)
+			}
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	const interval = 10 * time.Minute
+	for _, c := range []struct {
+		b    Backoff
+		n    int
+		want time.Duration
+	}{
+		{Backoff{Jitter: -1}, 1, 30 * time.Second},
+		{Backoff{Jitter: -1}, 2, time.Minute},
+		{Backoff{Jitter: -1}, 3, 2 * time.Minute},
+		{Backoff{Jitter: -1}, 10, interval},
+		{Backoff{Min: time.Second, Max: 5 * time.Second, Factor: 3, Jitter: -1}, 2, 3 * time.Second},
+		{Backoff{Min: time.Second, Max: 5 * time.Second, Factor: 3, Jitter: -1}, 3, 5 * time.Second},
+		{Backoff{Min: time.Second, Factor: 0.5, Jitter: -1}, 2, 2 * time.Second},
+	} {
+		u := &Updater{Backoff: c.b}
+		if got := u.backoff(c.n, interval); got != c.want {
+			t.Errorf(`backoff(%d) with %+v = %v, want %v`, c.n, c.b, got, c.want)
+		}
+	}
+
+	// Jitter only shortens the delay.
+	u := &Updater{Backoff: Backoff{Min: time.Minute, Jitter: 0.5}}
+	for i := 0; i < 100; i++ {
+		if d := u.backoff(1, interval); d > time.Minute || d < 30*time.Second {
+			t.Fatalf(`backoff with jitter %v`, d)
+		}
+	}
+}
+
+func TestRecoverDelay(t *testing.T) {
+	const interval = 10 * time.Minute
+	for _, c := range []struct {
+		b    Backoff
+		want time.Duration
+	}{
+		{Backoff{}, 30 * time.Second},
+		{Backoff{Min: time.Minute}, time.Minute},
+		{Backoff{Recover: 5 * time.Second}, 5 * time.Second},
+		{Backoff{Recover: -1}, interval},
+		{Backoff{Recover: time.Hour}, interval},
+	} {
+		u := &Updater{Backoff: c.b}
+		if got := u.recoverDelay(interval); got != c.want {
+			t.Errorf(`recoverDelay with %+v = %v, want %v`, c.b, got, c.want)
+		}
+	}
+}
+
+func TestHealthy(t *testing.T) {
+	now := time.Now()
+	u := &Updater{Interval: time.Minute}
+	if !u.Healthy(now) {
+		t.Error(`unhealthy before the first cycle`)
+	}
+	u.observe(now, errors.New(`failed`))
+	for _, c := range []struct {
+		at   time.Time
+		want bool
+	}{
+		{now.Add(time.Minute), true},
+		{now.Add(4 * time.Minute), false},
+	} {
+		if got := u.Healthy(c.at); got != c.want {
+			t.Errorf(`Healthy after %v = %v, want %v`, c.at.Sub(now), got, c.want)
+		}
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	u := &Updater{}
+	u.observe(time.Now(), nil)
+	u.observe(time.Now(), errors.New(`failed`))
+	u.detected(update.Addrs{IPv4: `203.0.113.1`}, false)
+	w := httptest.NewRecorder()
+	u.Handler().ServeHTTP(w, httptest.NewRequest(`GET`, `/metrics`, nil))
+	for _, want := range []string{
+		"dynv6_updater_consecutive_failures 1\n",
+		"dynv6_updater_cycles_total{result=\"ok\"} 1\n",
+		"dynv6_updater_cycles_total{result=\"error\"} 1\n",
+		"dynv6_updater_address_info{family=\"ipv4\",address=\"203.0.113.1\"} 1\n",
+		"dynv6_updater_shared_ipv4 0\n",
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("metrics lack %q:\n%s", want, w.Body)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"time"
+
+	"github.com/ZxwyProject/dynv6"
+	"github.com/ZxwyProject/libdynv6"
+	"github.com/ZxwyProject/libdynv6/update"
+)
+
+// ErrNotVerified is returned for targets whose push could not be verified.
+// The push is repeated in the next cycle.
+var ErrNotVerified = errors.New(`update not verified`)
+
+// Verification defaults.
+const (
+	DefaultVerifyTimeout  = 2 * time.Minute
+	DefaultVerifyInterval = 5 * time.Second
+)
+
+// Verify configures the check after each push that the new addresses are
+// live. Nothing is checked by default.
+type Verify struct {
+	// Wait until the nameservers configured in the Propagation of the
+	// Provider, by default those of dynv6, answer with the new address
+	// records, see [libdynv6.Provider.WaitForRecord].
+	DNS bool `json:"dns,omitempty"`
+
+	// URL which must answer with a 2xx or 3xx status after the DNS check,
+	// e.g. a health endpoint served under the updated name.
+	URL string `json:"url,omitempty"`
+
+	Timeout  time.Duration `json:"timeout,omitempty"`  // overall, defaults to DefaultVerifyTimeout
+	Interval time.Duration `json:"interval,omitempty"` // between URL attempts, defaults to DefaultVerifyInterval
+}
+
+func (v *Verify) enabled() bool {
+	return v.DNS || v.URL != ``
+}
+
+// verify checks the records pushed for t, see Verify.
+func (u *Updater) verify(ctx context.Context, t *Target, a update.Addrs, prefix netip.Prefix) error {
+	v := u.Verify
+	if !v.enabled() {
+		return nil
+	}
+	if v.Timeout <= 0 {
+		v.Timeout = DefaultVerifyTimeout
+	}
+	if v.Interval <= 0 {
+		v.Interval = DefaultVerifyInterval
+	}
+	ctx, cancel := context.WithTimeout(ctx, v.Timeout)
+	defer cancel()
+
+	if v.DNS {
+		r, err := hostRecords(t, a, prefix)
+		if err != nil {
+			return err
+		}
+		p := u.Provider
+		if p == nil {
+			p = &libdynv6.Provider{}
+		}
+		for _, x := range r {
+			if err := p.WaitForRecord(ctx, t.Zone, x); err != nil {
+				return fmt.Errorf(`%w: %v`, ErrNotVerified, err)
+			}
+		}
+	}
+	if v.URL != `` {
+		if err := pollURL(ctx, v.URL, v.Interval); err != nil {
+			return fmt.Errorf(`%w: %s: %v`, ErrNotVerified, v.URL, err)
+		}
+	}
+	if dynv6.Debug {
+		dynv6.DbgLog.Println(`[Dynv6-debug/updater]`, t.Zone, `verified`)
+	}
+	return nil
+}
+
+// pollURL fetches url every interval until it answers with a 2xx or 3xx
+// status or ctx is done, and returns the last error then.
+func pollURL(ctx context.Context, url string, interval time.Duration) error {
+	c := &http.Client{
+		// Redirects are success already.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	tk := time.NewTicker(interval)
+	defer tk.Stop()
+	for {
+		err := getURL(ctx, c, url)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-tk.C:
+		}
+	}
+}
+
+func getURL(ctx context.Context, c *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return errors.New(resp.Status)
+	}
+	return nil
+}
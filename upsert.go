@@ -0,0 +1,108 @@
+package libdynv6
+
+import (
+	"context"
+
+	"github.com/ZxwyProject/dynv6"
+	"github.com/ZxwyProject/libdynv6/diff"
+	"github.com/libdns/libdns"
+)
+
+// Upsert statuses.
+const (
+	UpsertCreated   = `created`
+	UpsertUpdated   = `updated`
+	UpsertUnchanged = `unchanged`
+	UpsertFailed    = `failed`
+)
+
+// UpsertResult is the outcome for one input record of [Provider.UpsertRecords].
+type UpsertResult struct {
+	Record libdns.Record
+	Status string // UpsertCreated, UpsertUpdated, UpsertUnchanged or UpsertFailed
+	ID     string // dynv6 record ID, if known
+	Err    error  // for UpsertFailed
+}
+
+// UpsertRecords makes each record exist in the zone and reports the outcome
+// per record instead of stopping at the first error. A record already present
+// is unchanged; otherwise an existing record of the same RRset which is not
+// among the input is updated, or a new one is created. Unlike SetRecords,
+// nothing is deleted. With an OwnerID, records of RRsets not owned fail with
+// [ErrNotOwned]. The error is only set if the zone cannot be read, or its
+// markers cannot be written.
+func (p *Provider) UpsertRecords(ctx context.Context, zone string, records []libdns.Record) ([]UpsertResult, error) {
+	p.o.Do(p.init)
+	unlock, err := p.lockZone(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	z, r, err := p.records(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	o := make([]UpsertResult, len(records))
+	var valid []libdns.Record
+	var idx []int // of valid in records
+	for i, x := range records {
+		o[i] = UpsertResult{Record: x, Status: UpsertUnchanged}
+		rr := x.RR()
+		if _, err := p.converter().FromLibdns(&rr); err != nil {
+			o[i].Status, o[i].Err = UpsertFailed, err
+			continue
+		}
+		valid = append(valid, x)
+		idx = append(idx, i)
+	}
+	cur, want, err := diffInputs(p.converter(), zone, r, valid)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Change
+	for _, op := range diff.RRsets(cur, want) {
+		if op.Kind == diff.Delete {
+			continue
+		}
+		res := &o[idx[op.To]]
+		lr := valid[op.To].RR()
+		dr, _ := p.converter().FromLibdns(&lr)
+
+		c := Change{Type: ChangeCreate, Record: lr}
+		if op.Kind == diff.Update {
+			c = Change{Type: ChangeUpdate, Record: lr, Old: op.Old, ID: string(r[op.From].ID)}
+		}
+		if p.OwnerID != `` {
+			if err = p.guard(zone, r, []Change{c}); err != nil {
+				res.Status, res.Err = UpsertFailed, err
+				continue
+			}
+		}
+
+		var d *dynv6.Record
+		if op.Kind == diff.Create {
+			d, err = p.recordAdd(ctx, zone, z, &lr, dr)
+			res.Status = UpsertCreated
+		} else {
+			res.ID = c.ID
+			d, err = p.recordUpd(ctx, zone, z, res.ID, &lr, dr)
+			res.Status = UpsertUpdated
+		}
+		if err != nil {
+			res.Status, res.Err = UpsertFailed, err
+			continue
+		}
+		applied = append(applied, c)
+		if d != nil && d.ID != `` {
+			res.ID = string(d.ID)
+		}
+	}
+	if p.OwnerID != `` {
+		if err = p.settle(ctx, zone, z, r, applied); err != nil {
+			return o, err
+		}
+	}
+	return o, nil
+}
@@ -6,26 +6,39 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/ZxwyProject/dynv6"
 	"github.com/libdns/libdns"
 )
 
-const ttl = 60 * time.Second // default
+// DefaultTTL is the TTL reported for records unless [Provider.TTL] is set.
+const DefaultTTL = 60 * time.Second
+
+const ttl = DefaultTTL
+
+// Record types without a constant in the dynv6 package.
+const (
+	rtNS = `NS`
+)
 
 var ErrUnsupportedType = errors.New(`unsupported record type`)
 
-func recordToLibdns(r *dynv6.Record) libdns.Record {
-	o := libdns.RR{
+func recordToLibdns(r *dynv6.Record) Record {
+	raw := *r
+	o := Record{
 		Name: r.Name,
 		TTL:  ttl,
 		Type: r.Type,
+		ID:   string(r.ID),
+		raw:  &raw,
 	}
 	switch r.Type {
-	case dynv6.RT_A, dynv6.RT_AAAA, dynv6.RT_CNAME, dynv6.RT_TXT, dynv6.RT_SPF:
+	case dynv6.RT_A, dynv6.RT_AAAA, dynv6.RT_CNAME, dynv6.RT_TXT, dynv6.RT_SPF, rtNS:
 		// libdns.Address{}.RR()
 		// libdns.CNAME{}.RR()
 		// libdns.TXT{}.RR()
+		// libdns.NS{}.RR()
 		o.Data = r.Data
 
 	case dynv6.RT_CAA:
@@ -36,102 +49,134 @@ func recordToLibdns(r *dynv6.Record) libdns.Record {
 
 	case dynv6.RT_MX:
 		// libdns.MX{}.RR()
-		if r.Priority != 0 || r.Data != `` {
-			o.Data = fmt.Sprintf("%d %s", r.Priority, r.Data)
-		}
+		// Zero is a valid preference, so all fields are always formatted.
+		o.Data = fmt.Sprintf("%d %s", r.Priority, target(r.Data))
 
 	case dynv6.RT_SRV:
 		// libdns.SRV{}.RR()
-		// TODO: Name?
-		if r.Priority != 0 || r.Weight != 0 || r.Port != 0 || r.Data != `` {
-			o.Data = fmt.Sprintf("%d %d %d %s", r.Priority, r.Weight, r.Port, r.Data)
-		}
+		// The name keeps its _service._proto labels, as dynv6 stores them.
+		o.Data = fmt.Sprintf("%d %d %d %s", r.Priority, r.Weight, r.Port, target(r.Data))
 
 	default:
-		// return nil
-		panic(`unreachable`)
+		// Unknown to this package, passed through as is.
+		o.Data = r.Data
 	}
-	return &o
+	return o
 }
 
-func recordFind(r []dynv6.Record, l *libdns.RR, n int) *dynv6.Record {
+// target returns the MX or SRV target s, or "." (no service, RFC 7505 and
+// RFC 2782) if it is empty, so the data keeps its number of fields.
+func target(s string) string {
+	if s == `` {
+		return `.`
+	}
+	return s
+}
+
+// recordFind returns the first of the n records in r matching l which is not
+// used yet, and marks it used. Name must be equal; empty Type or Data of l
+// match any value, as in [libdns.RecordDeleter]. Target names are compared
+// in canonical form. used may be nil.
+func recordFind(c RecordConverter, zone string, r []dynv6.Record, l *libdns.RR, n int, used []bool) (*dynv6.Record, error) {
+	var dr *dynv6.RecordReq
+	if l.Type != `` && l.Data != `` {
+		var err error
+		if dr, err = c.FromLibdns(l); err != nil {
+			return nil, err
+		}
+		dr = canonicalReq(zone, dr)
+	}
 	for i := 0; i < n; i++ {
 		a := &r[i]
-		if a.Type == l.Type && a.Name == l.Name {
-			return a
+		if (used != nil && used[i]) || a.Name != recordName(l.Name) || (l.Type != `` && a.Type != l.Type) {
+			continue
+		}
+		if dr != nil {
+			x := *a
+			x.Data = canonicalData(zone, x.Type, x.Data)
+			if !recordEqual(&x, dr) {
+				continue
+			}
+		}
+		if used != nil {
+			used[i] = true
 		}
+		return a, nil
+	}
+	return nil, nil
+}
+
+// recordName returns the dynv6 form of the relative record name s: the
+// apex is "", not "@" as returned by [libdns.RelativeName].
+func recordName(s string) string {
+	if s == `@` {
+		return ``
 	}
-	return nil
+	return s
 }
 
 func recordFromLibdns(l *libdns.RR) (*dynv6.RecordReq, error) {
 	o := dynv6.RecordReq{
-		Name: l.Name,
+		Name: recordName(l.Name),
 		Type: l.Type,
 	}
 	// l.Parse()
 	switch l.Type {
-	case dynv6.RT_A, dynv6.RT_AAAA, dynv6.RT_CNAME, dynv6.RT_TXT, dynv6.RT_SPF:
+	case dynv6.RT_A, dynv6.RT_AAAA, dynv6.RT_CNAME, dynv6.RT_TXT, dynv6.RT_SPF, rtNS:
 		o.Data = l.Data
 
 	case dynv6.RT_CAA:
-		fields := strings.Fields(l.Data)
-		if expectedLen := 3; len(fields) != expectedLen {
-			return nil, fmt.Errorf(`malformed CAA value; expected %d fields in the form 'flags tag "value"'`, expectedLen)
+		fields, err := dataFields(l, 3, `flags tag "value"`)
+		if err != nil {
+			return nil, err
 		}
-
-		flags, err := strconv.ParseUint(fields[0], 10, 8)
+		flags, err := dataUint(l, `flags`, fields[0], 8)
+		if err != nil {
+			return nil, err
+		}
+		if err = caaCheck(uint8(flags), fields[1]); err != nil {
+			return nil, err
+		}
+		value, err := caaValue(fields[2])
 		if err != nil {
-			return nil, fmt.Errorf("invalid flags %s: %v", fields[0], err)
+			return nil, fmt.Errorf(`invalid CAA value %s: %v`, fields[2], err)
 		}
 
 		o.Flags = uint8(flags)
 		o.Tag = fields[1]
-		o.Data = strings.Trim(fields[2], `"`)
+		o.Data = value
 
 	case dynv6.RT_MX:
-		fields := strings.Fields(l.Data)
-		if expectedLen := 2; len(fields) != expectedLen {
-			return nil, fmt.Errorf("malformed MX value; expected %d fields in the form 'preference target'", expectedLen)
+		fields, err := dataFields(l, 2, `preference target`)
+		if err != nil {
+			return nil, err
 		}
-
-		priority, err := strconv.ParseUint(fields[0], 10, 16)
+		priority, err := dataUint(l, `priority`, fields[0], 16)
 		if err != nil {
-			return nil, fmt.Errorf("invalid priority %s: %v", fields[0], err)
+			return nil, err
 		}
 
 		o.Priority = uint16(priority)
 		o.Data = fields[1]
 
 	case dynv6.RT_SRV:
-		fields := strings.Fields(l.Data)
-		if expectedLen := 4; len(fields) != expectedLen {
-			return nil, fmt.Errorf("malformed SRV value; expected %d fields in the form 'priority weight port target'", expectedLen)
+		fields, err := dataFields(l, 4, `priority weight port target`)
+		if err != nil {
+			return nil, err
 		}
-
-		priority, err := strconv.ParseUint(fields[0], 10, 16)
+		priority, err := dataUint(l, `priority`, fields[0], 16)
 		if err != nil {
-			return nil, fmt.Errorf("invalid priority %s: %v", fields[0], err)
+			return nil, err
 		}
-		weight, err := strconv.ParseUint(fields[1], 10, 16)
+		weight, err := dataUint(l, `weight`, fields[1], 16)
 		if err != nil {
-			return nil, fmt.Errorf("invalid weight %s: %v", fields[0], err)
+			return nil, err
 		}
-		port, err := strconv.ParseUint(fields[2], 10, 16)
+		port, err := dataUint(l, `port`, fields[2], 16)
 		if err != nil {
-			return nil, fmt.Errorf("invalid port %s: %v", fields[0], err)
+			return nil, err
 		}
 
-		// parts := strings.SplitN(l.Name, ".", 3)
-		// if len(parts) < 2 {
-		// 	return nil, fmt.Errorf("name %v does not contain enough fields; expected format: '_service._proto.name' or '_service._proto'", r.Name)
-		// }
-		// name := "@"
-		// if len(parts) == 3 {
-		// 	name = parts[2]
-		// }
-		// TODO: Name?
-
 		o.Priority = uint16(priority)
 		o.Weight = uint16(weight)
 		o.Port = uint16(port)
@@ -142,3 +187,52 @@ func recordFromLibdns(l *libdns.RR) (*dynv6.RecordReq, error) {
 	}
 	return &o, nil
 }
+
+// dataFields splits the Data of l into exactly n whitespace-separated fields.
+// For CAA, the last field is the rest of Data and may contain spaces.
+func dataFields(l *libdns.RR, n int, form string) ([]string, error) {
+	var o []string
+	if l.Type == dynv6.RT_CAA {
+		d := strings.TrimSpace(l.Data)
+		for len(o) < n-1 {
+			i := strings.IndexFunc(d, unicode.IsSpace)
+			if i < 0 {
+				break
+			}
+			o = append(o, d[:i])
+			d = strings.TrimLeftFunc(d[i:], unicode.IsSpace)
+		}
+		if d != `` {
+			o = append(o, d)
+		}
+	} else {
+		o = strings.Fields(l.Data)
+	}
+	if len(o) != n {
+		return nil, fmt.Errorf(`malformed %s value %q; expected %d fields in the form '%s'`, l.Type, l.Data, n, form)
+	}
+	return o, nil
+}
+
+// dataUint parses the field name of l.
+func dataUint(l *libdns.RR, name, s string, bits int) (uint64, error) {
+	n, err := strconv.ParseUint(s, 10, bits)
+	if err != nil {
+		return 0, fmt.Errorf(`invalid %s %s %s: %v`, l.Type, name, s, errors.Unwrap(err))
+	}
+	return n, nil
+}
+
+// caaValue returns the CAA value s without quotes.
+func caaValue(s string) (string, error) {
+	if !strings.HasPrefix(s, `"`) {
+		if strings.ContainsAny(s, `"`) || strings.IndexFunc(s, unicode.IsSpace) >= 0 {
+			return ``, errors.New(`unquoted value must not contain quotes or spaces`)
+		}
+		return s, nil
+	}
+	if len(s) < 2 || !strings.HasSuffix(s, `"`) {
+		return ``, errors.New(`missing closing quote`)
+	}
+	return strconv.Unquote(s)
+}
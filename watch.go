@@ -0,0 +1,129 @@
+package libdynv6
+
+import (
+	"context"
+	"time"
+
+	"github.com/ZxwyProject/dynv6"
+	"github.com/libdns/libdns"
+)
+
+// Watch event types.
+const (
+	EventAdded    = `added`
+	EventRemoved  = `removed`
+	EventModified = `modified`
+	EventError    = `error`
+)
+
+// DefaultWatchInterval is the interval of [Provider.WatchZone] if none is given.
+const DefaultWatchInterval = time.Minute
+
+// ZoneEvent is a change of a zone detected by [Provider.WatchZone].
+type ZoneEvent struct {
+	Type   string     `json:"type"`          // EventAdded, EventRemoved, EventModified or EventError
+	ID     string     `json:"id,omitempty"`  // dynv6 record ID
+	Record libdns.RR  `json:"record"`        // new record, or the removed one
+	Old    *libdns.RR `json:"old,omitempty"` // record before a modification
+	Err    error      `json:"-"`             // for EventError, polling continues
+}
+
+// WatchZone polls zone every interval and sends an event for each record
+// added, removed or modified since the previous poll, e.g. by an edit in
+// the dynv6 web UI. The initial state produces no events. Failed polls
+// produce an EventError. The channel is closed when ctx is done or p is
+// closed, see [Provider.Close]. If interval is not positive,
+// DefaultWatchInterval is used.
+func (p *Provider) WatchZone(ctx context.Context, zone string, interval time.Duration) <-chan ZoneEvent {
+	p.o.Do(p.init)
+	ch := make(chan ZoneEvent)
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	go func() {
+		defer close(ch)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		var last map[string]dynv6.Record
+		for {
+			_, r, err := p.records(ctx, zone)
+			if err != nil {
+				if ctx.Err() != nil || !p.sendEvent(ctx, ch, ZoneEvent{Type: EventError, Err: err}) {
+					return
+				}
+			} else {
+				cur := make(map[string]dynv6.Record, len(r))
+				for i := range r {
+					cur[string(r[i].ID)] = r[i]
+				}
+				if last != nil {
+					for _, e := range p.diffWatch(last, cur) {
+						if !p.sendEvent(ctx, ch, e) {
+							return
+						}
+					}
+				}
+				last = cur
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-p.quit:
+				return
+			case <-t.C:
+			}
+		}
+	}()
+	return ch
+}
+
+func (p *Provider) sendEvent(ctx context.Context, ch chan<- ZoneEvent, e ZoneEvent) bool {
+	select {
+	case ch <- e:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-p.quit:
+		return false
+	}
+}
+
+// diffWatch returns the events turning last into cur, keyed by record ID.
+func (p *Provider) diffWatch(last, cur map[string]dynv6.Record) []ZoneEvent {
+	var o []ZoneEvent
+	for id, c := range cur {
+		c := c
+		l, ok := last[id]
+		switch {
+		case !ok:
+			o = append(o, ZoneEvent{Type: EventAdded, ID: id, Record: p.libdnsRecord(&c).RR()})
+		case !recordEqual(&l, recordReq(&c)):
+			old := p.libdnsRecord(&l).RR()
+			o = append(o, ZoneEvent{Type: EventModified, ID: id, Record: p.libdnsRecord(&c).RR(), Old: &old})
+		}
+	}
+	for id, l := range last {
+		l := l
+		if _, ok := cur[id]; !ok {
+			o = append(o, ZoneEvent{Type: EventRemoved, ID: id, Record: p.libdnsRecord(&l).RR()})
+		}
+	}
+	return o
+}
+
+// recordReq returns the content of r as a request.
+func recordReq(r *dynv6.Record) *dynv6.RecordReq {
+	return &dynv6.RecordReq{
+		Type:     r.Type,
+		Name:     r.Name,
+		Data:     r.Data,
+		Priority: r.Priority,
+		Flags:    r.Flags,
+		Tag:      r.Tag,
+		Weight:   r.Weight,
+		Port:     r.Port,
+	}
+}
@@ -0,0 +1,21 @@
+package libdynv6_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ZxwyProject/libdynv6/dynv6test"
+)
+
+func TestWatchZoneDefaultInterval(t *testing.T) {
+	const zone = `example.dynv6.net`
+	m := dynv6test.NewMemory(zone)
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := m.WatchZone(ctx, zone, 0)
+	cancel()
+	for e := range ch {
+		if e.Err != nil && ctx.Err() == nil {
+			t.Fatal(e.Err)
+		}
+	}
+}
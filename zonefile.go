@@ -0,0 +1,171 @@
+package libdynv6
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/miekg/dns"
+)
+
+// ImportResult is the outcome of importing a single record.
+type ImportResult struct {
+	Record  libdns.RR `json:"record"`
+	Skipped bool      `json:"skipped,omitempty"` // not managed through dynv6, e.g. SOA
+	Err     error     `json:"-"`
+}
+
+// ImportZone parses a zone file (RFC 1035) and sets its records in zone,
+// one RRset at a time, see [Provider.SetRecords]. SOA and apex NS records
+// are managed by dynv6 and skipped.
+//
+// The returned error is only set if the zone file cannot be parsed; errors of
+// individual records are reported in the results.
+func (p *Provider) ImportZone(ctx context.Context, zone string, r io.Reader) ([]ImportResult, error) {
+	rrs, err := ParseZoneFile(r, zone)
+	if err != nil {
+		return nil, err
+	}
+	o := make([]ImportResult, len(rrs))
+
+	// group by RRset, keeping the order of the file
+	type key struct{ name, typ string }
+	var keys []key
+	sets := make(map[key][]int)
+	for i, rr := range rrs {
+		o[i].Record = rr
+		if rr.Type == `SOA` || rr.Type == rtNS && rr.Name == `@` {
+			o[i].Skipped = true
+			continue
+		}
+		k := key{rr.Name, rr.Type}
+		if sets[k] == nil {
+			keys = append(keys, k)
+		}
+		sets[k] = append(sets[k], i)
+	}
+
+	for _, k := range keys {
+		idx := sets[k]
+		in := make([]libdns.Record, len(idx))
+		for j, i := range idx {
+			in[j] = rrs[i]
+		}
+		_, err := p.SetRecords(ctx, zone, in)
+		for _, i := range idx {
+			o[i].Err = err
+		}
+	}
+	return o, nil
+}
+
+// ParseZoneFile parses a zone file (RFC 1035) with origin zone and returns its
+// records with names relative to zone.
+func ParseZoneFile(r io.Reader, zone string) ([]libdns.RR, error) {
+	origin := dns.Fqdn(zone)
+	zp := dns.NewZoneParser(r, origin, ``)
+
+	var o []libdns.RR
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		o = append(o, rrFromDNS(rr, origin))
+	}
+	if err := zp.Err(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// rrFromDNS converts a miekg/dns RR to a libdns.RR relative to origin.
+func rrFromDNS(rr dns.RR, origin string) libdns.RR {
+	h := rr.Header()
+	o := libdns.RR{
+		Name: libdns.RelativeName(h.Name, origin),
+		TTL:  time.Duration(h.Ttl) * time.Second,
+		Type: dns.TypeToString[h.Rrtype],
+	}
+	switch x := rr.(type) {
+	case *dns.TXT:
+		// unescaped, see libdns.RR.Data
+		o.Data = strings.Join(x.Txt, ``)
+	case *dns.SPF:
+		o.Data = strings.Join(x.Txt, ``)
+	default:
+		o.Data = strings.TrimSpace(strings.TrimPrefix(rr.String(), h.String()))
+	}
+	return o
+}
+
+// ExportZone writes the records of zone to w as an RFC 1035 zone file
+// with $ORIGIN and per-record TTLs.
+func (p *Provider) ExportZone(ctx context.Context, zone string, w io.Writer) error {
+	r, err := p.GetRecords(ctx, zone)
+	if err != nil {
+		return err
+	}
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "$ORIGIN %s\n", dns.Fqdn(zoneName(zone)))
+	fmt.Fprintf(b, "$TTL %d\n", int(p.ttl()/time.Second))
+	for _, x := range r {
+		b.WriteString(zoneFileLine(x.RR()))
+		b.WriteByte('\n')
+	}
+	return b.Flush()
+}
+
+// zoneFileLine formats rr as a zone file entry.
+func zoneFileLine(rr libdns.RR) string {
+	name := rr.Name
+	if name == `` {
+		name = `@`
+	}
+	data := rr.Data
+	switch rr.Type {
+	case `TXT`, `SPF`:
+		data = quoteTXT(data)
+	case `CNAME`, rtNS:
+		data = dns.Fqdn(data)
+	case `MX`, `SRV`:
+		// target is the last field
+		if f := strings.Fields(data); len(f) > 0 {
+			f[len(f)-1] = dns.Fqdn(f[len(f)-1])
+			data = strings.Join(f, ` `)
+		}
+	}
+	return fmt.Sprintf("%s\t%d\tIN\t%s\t%s", name, int(rr.TTL/time.Second), rr.Type, data)
+}
+
+// quoteTXT returns s as quoted, escaped character-strings of at most 255 bytes.
+func quoteTXT(s string) string {
+	if s == `` {
+		return `""`
+	}
+	var b strings.Builder
+	for len(s) > 0 {
+		n := len(s)
+		if n > 255 {
+			n = 255
+		}
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteByte('"')
+		for i := 0; i < n; i++ {
+			switch c := s[i]; {
+			case c == '"' || c == '\\':
+				b.WriteByte('\\')
+				b.WriteByte(c)
+			case c < 0x20 || c > 0x7e:
+				fmt.Fprintf(&b, `\%03d`, c)
+			default:
+				b.WriteByte(c)
+			}
+		}
+		b.WriteByte('"')
+		s = s[n:]
+	}
+	return b.String()
+}
@@ -0,0 +1,75 @@
+package libdynv6
+
+import (
+	"context"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Zone sort orders for [ZoneFilter].
+const (
+	SortByName    = `name`
+	SortByCreated = `created`
+	SortByUpdated = `updated`
+)
+
+// ZoneFilter selects and orders zones in [Provider.ListZonesFiltered].
+// All conditions must match; empty conditions match everything.
+type ZoneFilter struct {
+	Suffix string         // zone name suffix of whole labels, e.g. "dynv6.net"
+	Glob   string         // zone name pattern, see [path.Match]
+	Regexp *regexp.Regexp // zone name pattern
+
+	Sort string // SortByName (default), SortByCreated or SortByUpdated
+	Desc bool   // descending order
+}
+
+func (f *ZoneFilter) match(name string) bool {
+	name = zoneName(name)
+	if s := strings.TrimPrefix(zoneName(f.Suffix), `.`); s != `` && name != s && !strings.HasSuffix(name, `.`+s) {
+		return false
+	}
+	if f.Glob != `` {
+		if ok, _ := path.Match(strings.ToLower(f.Glob), name); !ok {
+			return false
+		}
+	}
+	if f.Regexp != nil && !f.Regexp.MatchString(name) {
+		return false
+	}
+	return true
+}
+
+// ListZonesFiltered returns the zones matching f, sorted as requested.
+func (p *Provider) ListZonesFiltered(ctx context.Context, f ZoneFilter) ([]ZoneInfo, error) {
+	z, err := p.ListZonesDetailed(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	o := z[:0]
+	for i := range z {
+		if f.match(z[i].Name) {
+			o = append(o, z[i])
+		}
+	}
+
+	var less func(a, b *ZoneInfo) bool
+	switch f.Sort {
+	case SortByCreated:
+		less = func(a, b *ZoneInfo) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	case SortByUpdated:
+		less = func(a, b *ZoneInfo) bool { return a.UpdatedAt.Before(b.UpdatedAt) }
+	default:
+		less = func(a, b *ZoneInfo) bool { return a.Name < b.Name }
+	}
+	sort.SliceStable(o, func(i, j int) bool {
+		if f.Desc {
+			return less(&o[j], &o[i])
+		}
+		return less(&o[i], &o[j])
+	})
+	return o, nil
+}
@@ -0,0 +1,76 @@
+package libdynv6
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// lockPollInterval is how often a held lock file is tried again.
+const lockPollInterval = 50 * time.Millisecond
+
+// zoneLocks serializes the mutations of each zone within a process.
+type zoneLocks struct {
+	mu sync.Mutex
+	m  map[string]chan struct{} // by zone name, holds a value while locked
+}
+
+func (l *zoneLocks) get(zone string) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.m == nil {
+		l.m = make(map[string]chan struct{})
+	}
+	c := l.m[zone]
+	if c == nil {
+		c = make(chan struct{}, 1)
+		l.m[zone] = c
+	}
+	return c
+}
+
+// lockZone serializes read-modify-write cycles on zone: it waits until no
+// other mutation of zone is running in p, and with LockDir set, in other
+// processes. The returned function releases the lock.
+func (p *Provider) lockZone(ctx context.Context, zone string) (func(), error) {
+	n := zoneName(zone)
+	c := p.zl.get(n)
+	select {
+	case c <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	dir := ExpandEnv(p.LockDir)
+	if dir == `` {
+		return func() { <-c }, nil
+	}
+
+	f, err := lockFile(ctx, filepath.Join(dir, n+`.lock`))
+	if err != nil {
+		<-c
+		return nil, err
+	}
+	return func() {
+		unlockFile(f)
+		<-c
+	}, nil
+}
+
+// lockFile polls until it holds the lock on the file name or ctx is done.
+func lockFile(ctx context.Context, name string) (*os.File, error) {
+	t := time.NewTicker(lockPollInterval)
+	defer t.Stop()
+	for {
+		f, ok, err := tryLockFile(name)
+		if err != nil || ok {
+			return f, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
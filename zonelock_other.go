@@ -0,0 +1,28 @@
+//go:build !unix
+
+package libdynv6
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// tryLockFile creates the file name exclusively; an existing file means
+// the lock is held. Unlike flock, the file stays if the process dies and
+// must then be removed by hand.
+func tryLockFile(name string) (*os.File, bool, error) {
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, fs.ErrExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return f, true, nil
+}
+
+func unlockFile(f *os.File) {
+	f.Close()
+	os.Remove(f.Name())
+}
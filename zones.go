@@ -0,0 +1,172 @@
+package libdynv6
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/ZxwyProject/dynv6"
+)
+
+// ErrZoneNotFound is returned if a zone does not exist in the account.
+var ErrZoneNotFound = errors.New(`zone not found`)
+
+// ZoneExists reports whether the zone name exists in the account.
+func (p *Provider) ZoneExists(ctx context.Context, name string) (bool, error) {
+	p.o.Do(p.init)
+	return p.zoneExists(ctx, name)
+}
+
+// ZoneID returns the dynv6 ID of the zone name, or [ErrZoneNotFound].
+func (p *Provider) ZoneID(ctx context.Context, name string) (string, error) {
+	p.o.Do(p.init)
+	z, err := p.zoneFind(ctx, name)
+	if err != nil {
+		return ``, err
+	}
+	return string(z.ID), nil
+}
+
+func (p *Provider) zoneExists(ctx context.Context, name string) (bool, error) {
+	_, err := p.zoneFind(ctx, name)
+	if err == ErrZoneNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// zoneFind looks up the zone name in the zone list, so that a missing
+// zone can be told apart from other errors.
+func (p *Provider) zoneFind(ctx context.Context, name string) (*dynv6.Zone, error) {
+	z, err := p.zones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name = zoneName(name)
+	for i := range z {
+		if zoneName(z[i].Name) == name {
+			return &z[i], nil
+		}
+	}
+	return nil, ErrZoneNotFound
+}
+
+// FindZone returns the zone of the account which contains the domain name,
+// the longest matching one if zones are nested, or [ErrZoneNotFound].
+func (p *Provider) FindZone(ctx context.Context, name string) (string, error) {
+	p.o.Do(p.init)
+	z, err := p.zones(ctx)
+	if err != nil {
+		return ``, err
+	}
+	name = zoneName(name)
+	o := ``
+	for i := range z {
+		n := zoneName(z[i].Name)
+		if (name == n || strings.HasSuffix(name, `.`+n)) && len(n) > len(o) {
+			o = n
+		}
+	}
+	if o == `` {
+		return ``, ErrZoneNotFound
+	}
+	return o, nil
+}
+
+// ZoneDelete deletes the zone name including all of its records.
+func (p *Provider) ZoneDelete(ctx context.Context, name string) error {
+	p.o.Do(p.init)
+	z, err := p.zone(ctx, name)
+	if err != nil {
+		return err
+	}
+	return p.zoneDel(ctx, name, z)
+}
+
+// zoneName returns the canonical form of a zone name, without trailing dot.
+func zoneName(s string) string {
+	return strings.ToLower(strings.TrimSuffix(s, `.`))
+}
+
+// ZoneInfo is the full metadata of a dynv6 zone.
+type ZoneInfo struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	IPv4Address string    `json:"ipv4address,omitempty"` // used for records with empty data
+	IPv6Prefix  string    `json:"ipv6prefix,omitempty"`  // used for host expansion
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func zoneInfo(z *dynv6.Zone) ZoneInfo {
+	return ZoneInfo{
+		ID:          string(z.ID),
+		Name:        z.Name,
+		IPv4Address: z.IPv4Address,
+		IPv6Prefix:  z.IPv6Prefix,
+		CreatedAt:   z.CreatedAt,
+		UpdatedAt:   z.UpdatedAt,
+	}
+}
+
+// GetZone returns the metadata of the zone name.
+func (p *Provider) GetZone(ctx context.Context, name string) (*ZoneInfo, error) {
+	p.o.Do(p.init)
+	z, err := p.zone(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	o := zoneInfo(z)
+	return &o, nil
+}
+
+// ListZonesDetailed is like [Provider.ListZones], but returns the full zone metadata.
+func (p *Provider) ListZonesDetailed(ctx context.Context) ([]ZoneInfo, error) {
+	p.o.Do(p.init)
+	z, err := p.zones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	l := len(z)
+	o := make([]ZoneInfo, l)
+
+	for i := 0; i < l; i++ {
+		o[i] = zoneInfo(&z[i])
+	}
+	return o, nil
+}
+
+// SetZoneAddresses updates the IPv4 address and IPv6 prefix of the zone.
+// Empty values are left unchanged.
+func (p *Provider) SetZoneAddresses(ctx context.Context, zone, ipv4, ipv6prefix string) (*ZoneInfo, error) {
+	if ipv4 != `` {
+		a, err := netip.ParseAddr(ipv4)
+		if err != nil || !a.Is4() {
+			return nil, fmt.Errorf(`invalid IPv4 address %q`, ipv4)
+		}
+	}
+	if ipv6prefix != `` {
+		a, err := netip.ParsePrefix(ipv6prefix)
+		if err != nil || !a.Addr().Is6() {
+			return nil, fmt.Errorf(`invalid IPv6 prefix %q`, ipv6prefix)
+		}
+	}
+
+	p.o.Do(p.init)
+	z, err := p.zone(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	z, err = p.zoneUpd(ctx, zone, z, &dynv6.ZoneReq{
+		IPv4Address: ipv4,
+		IPv6Prefix:  ipv6prefix,
+	})
+	if err != nil {
+		return nil, err
+	}
+	o := zoneInfo(z)
+	return &o, nil
+}
@@ -0,0 +1,72 @@
+package libdynv6
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// largestRRsets is the number of RRsets reported in [ZoneStats.LargestRRsets].
+const largestRRsets = 5
+
+// RRsetSize is the number of records sharing a name and type.
+type RRsetSize struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Size int    `json:"size"`
+}
+
+// ZoneStats summarizes the records of a zone.
+type ZoneStats struct {
+	Zone          string         `json:"zone"`
+	Records       int            `json:"records"`
+	Types         map[string]int `json:"types"`          // records per type
+	LargestRRsets []RRsetSize    `json:"largest_rrsets"` // largest first
+
+	// dynv6 keeps no per-record timestamps, so these are the zone's.
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ZoneStats returns record statistics of the zone.
+func (p *Provider) ZoneStats(ctx context.Context, zone string) (*ZoneStats, error) {
+	p.o.Do(p.init)
+	z, r, err := p.records(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	o := ZoneStats{
+		Zone:      z.Name,
+		Records:   len(r),
+		Types:     make(map[string]int),
+		CreatedAt: z.CreatedAt,
+		UpdatedAt: z.UpdatedAt,
+	}
+
+	type key struct{ name, typ string }
+	sets := make(map[key]int)
+	for i := range r {
+		o.Types[r[i].Type]++
+		sets[key{r[i].Name, r[i].Type}]++
+	}
+
+	s := make([]RRsetSize, 0, len(sets))
+	for k, n := range sets {
+		s = append(s, RRsetSize{k.name, k.typ, n})
+	}
+	sort.Slice(s, func(i, j int) bool {
+		if s[i].Size != s[j].Size {
+			return s[i].Size > s[j].Size
+		}
+		if s[i].Name != s[j].Name {
+			return s[i].Name < s[j].Name
+		}
+		return s[i].Type < s[j].Type
+	})
+	if len(s) > largestRRsets {
+		s = s[:largestRRsets]
+	}
+	o.LargestRRsets = s
+
+	return &o, nil
+}